@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"bus-manager/internal/database"
 	"bus-manager/internal/handlers"
@@ -27,15 +29,36 @@ func main() {
 	// Initialize Redis
 	redisClient := database.InitRedis()
 
-	// Initialize Gin router
-	r := gin.Default()
+	// Initialize Gin router. Query strings are left out of the access log so
+	// credentials passed in URLs are never written to disk.
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		path, _, _ := strings.Cut(param.Path, "?")
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			path,
+			param.ErrorMessage,
+		)
+	}), gin.Recovery())
 
 	// Add CORS middleware
 	r.Use(middleware.CORS())
 
+	// Initialize WebSocket hub for real-time updates
+	hub := handlers.NewWSHub(db, redisClient)
+	go hub.Run()
+
+	// Start the world loop that advances the game clock
+	world := handlers.NewWorld(db, redisClient, hub)
+	go world.Run()
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(db, redisClient)
-	gameHandler := handlers.NewGameHandler(db, redisClient)
+	gameHandler := handlers.NewGameHandler(db, redisClient, hub)
 
 	// Health check endpoint (no auth required)
 	r.GET("/health", func(c *gin.Context) {
@@ -63,18 +86,100 @@ func main() {
 		{
 			game.GET("/company", gameHandler.GetCompany)
 			game.POST("/company", gameHandler.CreateCompany)
+			game.GET("/progression", gameHandler.GetProgression)
+			game.GET("/reputation", gameHandler.GetReputation)
+			game.GET("/emissions", gameHandler.GetEmissions)
+			game.GET("/incidents", gameHandler.GetIncidents)
+			game.GET("/insurance/quote", gameHandler.GetInsuranceQuote)
+			game.GET("/insurance/policies", gameHandler.GetInsurancePolicies)
+			game.POST("/insurance/policies", gameHandler.BuyInsurance)
+			game.DELETE("/insurance/policies/:id", gameHandler.CancelInsurance)
+			game.GET("/achievements", gameHandler.GetAchievements)
 			game.GET("/depots", gameHandler.GetDepots)
 			game.POST("/depots", gameHandler.CreateDepot)
+			game.GET("/depots/quote", gameHandler.QuoteDepotLand)
+			game.POST("/depots/:id/upgrades", gameHandler.UpgradeDepot)
+			game.DELETE("/depots/:id", gameHandler.SellDepot)
 			game.GET("/buses", gameHandler.GetBuses)
 			game.POST("/buses", gameHandler.CreateBus)
+			game.GET("/bus-models", gameHandler.GetBusModels)
+			game.POST("/buses/:id/charge", gameHandler.ChargeBus)
+			game.POST("/buses/:id/refuel", gameHandler.RefuelBus)
+			game.POST("/buses/:id/transfer", gameHandler.TransferBus)
+			game.GET("/buses/:id/valuation", gameHandler.GetBusValuation)
+			game.POST("/buses/:id/sell", gameHandler.SellBus)
+			game.POST("/buses/:id/scrap", gameHandler.ScrapBus)
+			game.GET("/buses/:id/reviews", gameHandler.GetBusReviews)
+			game.GET("/buses/:id/advertising", gameHandler.GetAdOffers)
+			game.POST("/buses/:id/advertising", gameHandler.SignAdvertisement)
+			game.DELETE("/buses/:id/advertising", gameHandler.TerminateAdvertisement)
+			game.GET("/market/listings", gameHandler.GetListings)
+			game.POST("/market/listings", gameHandler.CreateListing)
+			game.DELETE("/market/listings/:id", gameHandler.CancelListing)
+			game.POST("/market/listings/:id/offers", gameHandler.CreateOffer)
+			game.GET("/market/offers", gameHandler.GetOffers)
+			game.POST("/market/offers/:id/accept", gameHandler.AcceptOffer)
+			game.POST("/market/offers/:id/reject", gameHandler.RejectOffer)
+			game.DELETE("/market/offers/:id", gameHandler.WithdrawOffer)
+			game.GET("/loans/offer", gameHandler.GetLoanOffer)
+			game.GET("/loans", gameHandler.GetLoans)
+			game.POST("/loans", gameHandler.TakeLoan)
+			game.GET("/loans/:id/schedule", gameHandler.GetLoanSchedule)
+			game.POST("/loans/:id/repay", gameHandler.RepayLoan)
 			game.GET("/routes", gameHandler.GetRoutes)
+			game.POST("/routes", gameHandler.CreateRoute)
+			game.GET("/cities", gameHandler.GetCities)
+			game.GET("/clock", gameHandler.GetClock)
+			game.GET("/seasons", gameHandler.GetSeasons)
+			game.GET("/weather", gameHandler.GetWeather)
+			game.GET("/permits", gameHandler.GetPermits)
+			game.POST("/routes/:id/permits", gameHandler.BuyPermit)
+			game.POST("/routes/:id/permits/bids", gameHandler.PlacePermitBid)
+			game.GET("/routes/:id/reviews", gameHandler.GetRouteReviews)
+			game.GET("/routes/:id/charges", gameHandler.GetRouteCharges)
+			game.POST("/permits/:id/renew", gameHandler.RenewPermit)
+			game.PUT("/permits/:id/fare", gameHandler.SetPermitFare)
+			game.DELETE("/permits/bids/:id", gameHandler.WithdrawPermitBid)
+			game.GET("/contracts", gameHandler.GetContracts)
+			game.POST("/contracts/:id/accept", gameHandler.AcceptContract)
+			game.POST("/contracts/:id/cancel", gameHandler.CancelContract)
+			game.GET("/charters", gameHandler.GetCharters)
+			game.POST("/charters/:id/book", gameHandler.BookCharter)
+			game.POST("/charters/:id/dispatch", gameHandler.DispatchCharter)
+			game.POST("/charters/:id/cancel", gameHandler.CancelCharter)
+			game.GET("/marketing/campaigns", gameHandler.GetCampaigns)
+			game.POST("/marketing/campaigns", gameHandler.CreateCampaign)
+			game.POST("/marketing/campaigns/:id/cancel", gameHandler.CancelCampaign)
+			game.GET("/marketing/analytics", gameHandler.GetMarketingAnalytics)
+			game.GET("/competitors", gameHandler.GetCompetitors)
+			game.POST("/ws-ticket", gameHandler.CreateWSTicket)
 			game.POST("/trips", gameHandler.CreateTrip)
 			game.GET("/trips/active", gameHandler.GetActiveTrips)
+			game.GET("/timetables", gameHandler.GetTimetables)
+			game.POST("/timetables", gameHandler.CreateTimetable)
+			game.DELETE("/timetables/:id", gameHandler.DeleteTimetable)
+		}
+
+		// Leaderboard routes (protected)
+		leaderboards := api.Group("/leaderboards")
+		leaderboards.Use(middleware.AuthMiddleware(redisClient))
+		{
+			leaderboards.GET("/:kind", gameHandler.GetLeaderboard)
+		}
+
+		// Admin routes (protected, administrators only)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(redisClient), middleware.AdminMiddleware(db))
+		{
+			admin.GET("/routes/pending", gameHandler.GetPendingRoutes)
+			admin.POST("/routes/:id/approve", gameHandler.ApproveRoute)
+			admin.POST("/routes/:id/reject", gameHandler.RejectRoute)
+			admin.POST("/permits/:id/revoke", gameHandler.RevokePermit)
 		}
 	}
 
 	// WebSocket route for real-time updates
-	r.GET("/ws/trips", handlers.HandleWebSocket)
+	r.GET("/ws/trips", hub.HandleWebSocket)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"bus-manager/internal/datapack"
+)
+
+// check-packs validates the bundled data packs together with the packs in a
+// directory, the way the server loads them, and summarizes the result.
+func main() {
+	dir := flag.String("dir", "", "directory containing extra data packs (*.json)")
+	flag.Parse()
+
+	world, err := datapack.Load(*dir)
+	if err != nil {
+		log.Fatalf("Invalid data packs:\n%v", err)
+	}
+
+	for _, pack := range world.Packs {
+		fmt.Printf("%-16s %-40s %3d cities %3d routes %2d ferries  (%s)\n",
+			pack.Name, pack.Title, len(pack.Cities), len(pack.Routes), len(pack.Ferries), pack.Source)
+	}
+	fmt.Printf("World: %d cities, %d routes, %d ferries, %d land regions\n",
+		len(world.Cities), len(world.Routes), len(world.Ferries), len(world.Land))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+
+	"bus-manager/internal/database"
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+
+	"github.com/joho/godotenv"
+)
+
+// Maximum distance between a line's endpoints and the route terminals before
+// the geometry is rejected as belonging to a different route.
+const maxEndpointOffsetKm = 25.0
+
+func main() {
+	dir := flag.String("dir", "data/geometry", "directory containing route GeoJSON files")
+	updateDistance := flag.Bool("update-distance", false, "replace route distance with the geometry length")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.geojson"))
+	if err != nil {
+		log.Fatal("Failed to list GeoJSON files:", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("No .geojson files found in %s", *dir)
+	}
+
+	imported := 0
+	for _, file := range files {
+		features, err := geo.ReadLineFeatures(file)
+		if err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			continue
+		}
+
+		for _, feature := range features {
+			name, _ := feature.Properties["route"].(string)
+			if name == "" {
+				log.Printf("Skipping feature in %s: missing \"route\" property", file)
+				continue
+			}
+
+			var route models.Route
+			if err := db.Where("name = ?", name).First(&route).Error; err != nil {
+				log.Printf("Skipping %s: route %q not found", file, name)
+				continue
+			}
+
+			points, ok := orientToRoute(feature.Points, route)
+			if !ok {
+				log.Printf("Skipping %s: endpoints do not match %s terminals", file, route.Name)
+				continue
+			}
+
+			path := geo.NewPath(points)
+			updates := map[string]interface{}{"geometry": geo.EncodePolyline(points)}
+			if *updateDistance {
+				updates["distance"] = path.Length()
+			}
+			if err := db.Model(&route).Updates(updates).Error; err != nil {
+				log.Printf("Failed to update route %s: %v", route.Name, err)
+				continue
+			}
+
+			log.Printf("Imported geometry for %s: %d points, %.1f km", route.Name, len(points), path.Length())
+			imported++
+		}
+	}
+
+	log.Printf("Imported geometry for %d routes", imported)
+}
+
+// orientToRoute checks the line runs between the route terminals, reversing it
+// when it was drawn from destination to origin.
+func orientToRoute(points []geo.Point, route models.Route) ([]geo.Point, bool) {
+	origin := geo.Point{Lat: route.OriginLat, Lng: route.OriginLng}
+	dest := geo.Point{Lat: route.DestLat, Lng: route.DestLng}
+	first, last := points[0], points[len(points)-1]
+
+	if geo.Haversine(first, origin) <= maxEndpointOffsetKm && geo.Haversine(last, dest) <= maxEndpointOffsetKm {
+		return points, true
+	}
+	if geo.Haversine(first, dest) <= maxEndpointOffsetKm && geo.Haversine(last, origin) <= maxEndpointOffsetKm {
+		reversed := make([]geo.Point, len(points))
+		for i, p := range points {
+			reversed[len(points)-1-i] = p
+		}
+		return reversed, true
+	}
+	return nil, false
+}
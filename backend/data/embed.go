@@ -0,0 +1,35 @@
+// Package data bundles the static world datasets shipped with the server.
+package data
+
+import "embed"
+
+// Packs holds the world map data packs: cities, terminals, routes, ferries and
+// the land depots may be built on, one JSON file per region.
+//
+//go:embed packs/*.json
+var Packs embed.FS
+
+// Progression holds the experience rules and the level curve with its unlocks.
+//
+//go:embed progression.json
+var Progression []byte
+
+// Reviews holds the passenger review templates in Indonesian and English.
+//
+//go:embed reviews.json
+var Reviews []byte
+
+// Achievements holds the achievement and quest definitions.
+//
+//go:embed achievements.json
+var Achievements []byte
+
+// Seasons holds the calendar of holidays that change travel demand.
+//
+//go:embed seasons.json
+var Seasons []byte
+
+// AIStrategies holds the strategies computer-controlled companies play by.
+//
+//go:embed ai_strategies.json
+var AIStrategies []byte
@@ -6,7 +6,10 @@ import (
 	"log"
 	"os"
 
+	"bus-manager/internal/datapack"
+	"bus-manager/internal/geo"
 	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
 
 	"github.com/go-redis/redis/v8"
 	"gorm.io/driver/postgres"
@@ -37,6 +40,30 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// A bus used to be able to hold the same coverage twice; keep the oldest
+	// active policy so the one-active-policy index (idx_active_policy) applies
+	if db.Migrator().HasTable(&models.InsurancePolicy{}) {
+		if err := db.Exec(`UPDATE insurance_policies SET status = 'cancelled'
+			WHERE status = 'active' AND EXISTS (
+				SELECT 1 FROM insurance_policies older
+				WHERE older.bus_id = insurance_policies.bus_id AND older.coverage = insurance_policies.coverage
+					AND older.status = 'active' AND older.id < insurance_policies.id)`).Error; err != nil {
+			return nil, fmt.Errorf("failed to cancel duplicate insurance policies: %w", err)
+		}
+	}
+
+	// Likewise a bus livery could be rented out twice; keep the oldest active
+	// advertisement so idx_active_advertisement applies
+	if db.Migrator().HasTable(&models.BusAdvertisement{}) {
+		if err := db.Exec(`UPDATE bus_advertisements SET status = 'terminated'
+			WHERE status = 'active' AND EXISTS (
+				SELECT 1 FROM bus_advertisements older
+				WHERE older.bus_id = bus_advertisements.bus_id
+					AND older.status = 'active' AND older.id < bus_advertisements.id)`).Error; err != nil {
+			return nil, fmt.Errorf("failed to terminate duplicate bus advertisements: %w", err)
+		}
+	}
+
 	// Auto migrate the schema
 	err = db.AutoMigrate(
 		&models.User{},
@@ -44,15 +71,58 @@ func InitDB() (*gorm.DB, error) {
 		&models.Depot{},
 		&models.Bus{},
 		&models.Route{},
+		&models.RouteStop{},
 		&models.Trip{},
+		&models.TripStop{},
 		&models.Driver{},
 		&models.BusUpgrade{},
 		&models.Transaction{},
+		&models.City{},
+		&models.Terminal{},
+		&models.WorldState{},
+		&models.RoutePermit{},
+		&models.PermitBid{},
+		&models.BusListing{},
+		&models.BusOffer{},
+		&models.Loan{},
+		&models.RouteReputation{},
+		&models.TripSatisfaction{},
+		&models.PassengerReview{},
+		&models.AchievementProgress{},
+		&models.Incident{},
+		&models.InsuranceClaim{},
+		&models.InsurancePolicy{},
+		&models.RouteCharge{},
+		&models.TripCharge{},
+		&models.SubsidyContract{},
+		&models.CharterJob{},
+		&models.TripParcel{},
+		&models.MarketingCampaign{},
+		&models.BusAdvertisement{},
+		&models.Timetable{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Insurance claims used to be unique per incident; they are now unique per
+	// incident and coverage (idx_incident_coverage), which AutoMigrate adds
+	// without dropping the old index
+	if db.Migrator().HasIndex(&models.InsuranceClaim{}, "idx_insurance_claims_incident_id") {
+		if err := db.Migrator().DropIndex(&models.InsuranceClaim{}, "idx_insurance_claims_incident_id"); err != nil {
+			return nil, fmt.Errorf("failed to drop the per-incident claim index: %w", err)
+		}
+	}
+
+	// Trips dispatched before they recorded their operator and powertrain
+	// take them from their bus
+	if err := db.Exec("UPDATE trips SET company_id = buses.company_id FROM buses WHERE buses.id = trips.bus_id AND trips.company_id = 0").Error; err != nil {
+		log.Printf("Warning: Failed to attribute trips to companies: %v", err)
+	}
+	if err := db.Exec("UPDATE trips SET powertrain = buses.powertrain FROM buses WHERE buses.id = trips.bus_id AND COALESCE(trips.powertrain, '') = ''").Error; err != nil {
+		log.Printf("Warning: Failed to record trip powertrains: %v", err)
+	}
+
 	// Seed initial data
 	if err := seedData(db); err != nil {
 		log.Printf("Warning: Failed to seed data: %v", err)
@@ -91,86 +161,15 @@ func getEnv(key, defaultValue string) string {
 }
 
 func seedData(db *gorm.DB) error {
-	// Seed initial routes for Indonesia (Java island)
-	routes := []models.Route{
-		{
-			Name:        "Jakarta - Bandung",
-			Origin:      "Jakarta",
-			Destination: "Bandung",
-			OriginLat:   -6.2088,
-			OriginLng:   106.8456,
-			DestLat:     -6.9175,
-			DestLng:     107.6191,
-			Distance:    150,
-			Duration:    180, // 3 hours
-			Popularity:  80,
-			Type:        "intercity",
-			MinBusType:  "normal",
-			BaseFare:    50000, // IDR
-		},
-		{
-			Name:        "Jakarta - Surabaya",
-			Origin:      "Jakarta",
-			Destination: "Surabaya",
-			OriginLat:   -6.2088,
-			OriginLng:   106.8456,
-			DestLat:     -7.2575,
-			DestLng:     112.7521,
-			Distance:    785,
-			Duration:    660, // 11 hours
-			Popularity:  90,
-			Type:        "interprovince",
-			MinBusType:  "high_decker",
-			BaseFare:    250000, // IDR
-		},
-		{
-			Name:        "Bandung - Yogyakarta",
-			Origin:      "Bandung",
-			Destination: "Yogyakarta",
-			OriginLat:   -6.9175,
-			OriginLng:   107.6191,
-			DestLat:     -7.7956,
-			DestLng:     110.3695,
-			Distance:    400,
-			Duration:    360, // 6 hours
-			Popularity:  70,
-			Type:        "intercity",
-			MinBusType:  "normal",
-			BaseFare:    120000, // IDR
-		},
-		{
-			Name:        "Surabaya - Malang",
-			Origin:      "Surabaya",
-			Destination: "Malang",
-			OriginLat:   -7.2575,
-			OriginLng:   112.7521,
-			DestLat:     -7.9797,
-			DestLng:     112.6304,
-			Distance:    90,
-			Duration:    120, // 2 hours
-			Popularity:  85,
-			Type:        "intercity",
-			MinBusType:  "normal",
-			BaseFare:    35000, // IDR
-		},
-		{
-			Name:        "Yogyakarta - Surakarta",
-			Origin:      "Yogyakarta",
-			Destination: "Surakarta",
-			OriginLat:   -7.7956,
-			OriginLng:   110.3695,
-			DestLat:     -7.5760,
-			DestLng:     110.8295,
-			Distance:    60,
-			Duration:    90, // 1.5 hours
-			Popularity:  75,
-			Type:        "intercity",
-			MinBusType:  "normal",
-			BaseFare:    25000, // IDR
-		},
-	}
-
-	for _, route := range routes {
+	world := datapack.Current()
+
+	if err := seedCities(db, world); err != nil {
+		return err
+	}
+
+	for _, packRoute := range world.Routes {
+		route := routeFromPack(world, packRoute)
+
 		var existingRoute models.Route
 		err := db.Where("name = ?", route.Name).First(&existingRoute).Error
 		if err == gorm.ErrRecordNotFound {
@@ -178,6 +177,127 @@ func seedData(db *gorm.DB) error {
 				return fmt.Errorf("failed to create route %s: %w", route.Name, err)
 			}
 			log.Printf("Created route: %s", route.Name)
+		} else if err == nil && len(route.Stops) > 0 {
+			// Backfill stops for routes seeded before multi-stop support
+			var stopCount int64
+			db.Model(&models.RouteStop{}).Where("route_id = ?", existingRoute.ID).Count(&stopCount)
+			if stopCount == 0 {
+				for i := range route.Stops {
+					route.Stops[i].RouteID = existingRoute.ID
+				}
+				if err := db.Create(&route.Stops).Error; err != nil {
+					return fmt.Errorf("failed to create stops for route %s: %w", route.Name, err)
+				}
+				log.Printf("Created %d stops for route: %s", len(route.Stops), route.Name)
+			}
+		}
+		if err == nil && len(route.Charges) > 0 {
+			// Backfill tolls and ferries for routes seeded before route charges
+			var chargeCount int64
+			db.Model(&models.RouteCharge{}).Where("route_id = ?", existingRoute.ID).Count(&chargeCount)
+			if chargeCount == 0 {
+				for i := range route.Charges {
+					route.Charges[i].RouteID = existingRoute.ID
+				}
+				if err := db.Create(&route.Charges).Error; err != nil {
+					return fmt.Errorf("failed to create charges for route %s: %w", route.Name, err)
+				}
+				log.Printf("Created %d charges for route: %s", len(route.Charges), route.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// routeFromPack builds a world route from its data pack definition. Stops are
+// placed at their terminals.
+func routeFromPack(world *datapack.World, r datapack.Route) models.Route {
+	stops := make([]models.RouteStop, len(r.Stops))
+	for i, stop := range r.Stops {
+		terminal, _ := world.Terminal(stop.City, stop.Terminal)
+		stops[i] = models.RouteStop{
+			Sequence:  i,
+			City:      stop.City,
+			Terminal:  terminal.Name,
+			Latitude:  terminal.Latitude,
+			Longitude: terminal.Longitude,
+			Distance:  stop.Distance,
+			Duration:  stop.Duration,
+			Fare:      stop.Fare,
+		}
+	}
+
+	charges := make([]models.RouteCharge, len(r.Charges))
+	for i, charge := range r.Charges {
+		fees := charge.Fees
+		name := charge.Name
+		if charge.Kind == simulation.ChargeFerry {
+			ferry, _ := world.Ferry(charge.Ferry)
+			fees, name = ferry.Fees, ferry.Name
+		}
+		charges[i] = models.RouteCharge{
+			Sequence:    i,
+			Kind:        charge.Kind,
+			Name:        name,
+			FeeSmall:    fees.Small,
+			FeeMedium:   fees.Medium,
+			FeeLarge:    fees.Large,
+			AltDistance: charge.AltDistance,
+			AltDuration: charge.AltDuration,
+		}
+	}
+
+	geometry := ""
+	if len(r.Geometry) >= 2 {
+		points := make([]geo.Point, len(r.Geometry))
+		for i, position := range r.Geometry {
+			points[i] = geo.Point{Lat: position[1], Lng: position[0]}
+		}
+		geometry = geo.EncodePolyline(points)
+	}
+
+	origin, destination := stops[0], stops[len(stops)-1]
+	return models.Route{
+		Name:        r.Name,
+		Origin:      origin.City,
+		Destination: destination.City,
+		OriginLat:   origin.Latitude,
+		OriginLng:   origin.Longitude,
+		DestLat:     destination.Latitude,
+		DestLng:     destination.Longitude,
+		Distance:    r.Distance(),
+		Duration:    r.Duration(),
+		Popularity:  r.Popularity,
+		Type:        r.Type,
+		MinBusType:  r.MinBusType,
+		BaseFare:    r.Fare(),
+		Geometry:    geometry,
+		Stops:       stops,
+		Charges:     charges,
+	}
+}
+
+func seedCities(db *gorm.DB, world *datapack.World) error {
+	for _, c := range world.Cities {
+		city := models.City{
+			Name:      c.Name,
+			Province:  c.Province,
+			Island:    c.Island,
+			Latitude:  c.Latitude,
+			Longitude: c.Longitude,
+		}
+		for _, t := range c.Terminals {
+			city.Terminals = append(city.Terminals, models.Terminal{Name: t.Name, Latitude: t.Latitude, Longitude: t.Longitude})
+		}
+
+		var existingCity models.City
+		err := db.Where("name = ?", city.Name).First(&existingCity).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := db.Create(&city).Error; err != nil {
+				return fmt.Errorf("failed to create city %s: %w", city.Name, err)
+			}
+			log.Printf("Created city: %s", city.Name)
 		}
 	}
 
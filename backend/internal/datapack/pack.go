@@ -0,0 +1,213 @@
+// Package datapack loads the world map from data packs: JSON files describing
+// the cities, terminals, routes, ferry crossings and buildable land of a
+// region. Packs may refer to cities and ferries of other packs, so they are
+// validated together once all are loaded.
+package datapack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"bus-manager/internal/simulation"
+)
+
+// Pack is a region of the world map.
+type Pack struct {
+	Name        string          `json:"name"` // unique id, e.g. "sumatra"
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Cities      []City          `json:"cities"`
+	Ferries     []Ferry         `json:"ferries"`
+	Routes      []Route         `json:"routes"`
+	Land        json.RawMessage `json:"land"` // GeoJSON polygons depots may be built on, with "name" and "island" properties
+
+	Source string `json:"-"` // file the pack was read from
+}
+
+type City struct {
+	Name      string     `json:"name"`
+	Province  string     `json:"province"`
+	Island    string     `json:"island"`
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	Terminals []Terminal `json:"terminals"`
+}
+
+type Terminal struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Ferry is a car ferry crossing between two islands.
+type Ferry struct {
+	Name     string               `json:"name"`
+	Islands  [2]string            `json:"islands"`
+	Fees     simulation.ClassFees `json:"fees"`
+	Duration int                  `json:"duration"` // minutes including loading
+}
+
+// Route is a world route. The first stop is the origin and the last the
+// destination; segment values are from the previous stop.
+type Route struct {
+	Name       string      `json:"name"` // defaults to "Origin - Destination"
+	Type       string      `json:"type"` // intercity, interprovince; derived from the provinces when empty
+	MinBusType string      `json:"min_bus_type"`
+	Popularity int         `json:"popularity"` // demand baseline 1-100
+	Stops      []Stop      `json:"stops"`
+	Charges    []Charge    `json:"charges"`
+	Geometry   [][]float64 `json:"geometry"` // optional road path as [longitude, latitude] positions
+}
+
+type Stop struct {
+	City     string  `json:"city"`
+	Terminal string  `json:"terminal"` // defaults to the city's first terminal
+	Distance float64 `json:"distance"` // km
+	Duration int     `json:"duration"` // minutes
+	Fare     float64 `json:"fare"`     // IDR
+}
+
+// Charge is a toll section or a ferry crossing on a route. Ferry charges name
+// the crossing and take its fees.
+type Charge struct {
+	Kind        string               `json:"kind"` // toll, ferry
+	Name        string               `json:"name"`
+	Ferry       string               `json:"ferry"`
+	Fees        simulation.ClassFees `json:"fees"`
+	AltDistance float64              `json:"alt_distance"` // extra km of the non-toll alternative
+	AltDuration int                  `json:"alt_duration"` // extra minutes of the non-toll alternative
+}
+
+var (
+	packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	busTypes        = map[string]bool{"normal": true, "high_decker": true, "super_high_decker": true, "double_decker": true}
+	routeTypes      = map[string]bool{"": true, "intercity": true, "interprovince": true}
+)
+
+// Parse reads a pack and checks it on its own. References to other packs are
+// checked by NewWorld.
+func Parse(raw []byte) (*Pack, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	var pack Pack
+	if err := decoder.Decode(&pack); err != nil {
+		return nil, fmt.Errorf("failed to parse data pack: %w", err)
+	}
+	if err := pack.validate(); err != nil {
+		return nil, fmt.Errorf("data pack %q: %w", pack.Name, err)
+	}
+	return &pack, nil
+}
+
+func (p *Pack) validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !packNamePattern.MatchString(p.Name) {
+		fail("name must be lowercase letters, digits and dashes")
+	}
+
+	for _, city := range p.Cities {
+		if city.Name == "" || city.Province == "" || city.Island == "" {
+			fail("city %q needs a name, province and island", city.Name)
+		}
+		if !validCoordinate(city.Latitude, city.Longitude) {
+			fail("city %s has invalid coordinates", city.Name)
+		}
+		if len(city.Terminals) == 0 {
+			fail("city %s has no terminals", city.Name)
+		}
+		terminals := map[string]bool{}
+		for _, terminal := range city.Terminals {
+			if terminal.Name == "" || terminals[terminal.Name] {
+				fail("city %s has a missing or duplicate terminal name %q", city.Name, terminal.Name)
+			}
+			terminals[terminal.Name] = true
+			if !validCoordinate(terminal.Latitude, terminal.Longitude) {
+				fail("terminal %s in %s has invalid coordinates", terminal.Name, city.Name)
+			}
+		}
+	}
+
+	for _, ferry := range p.Ferries {
+		if ferry.Name == "" || ferry.Islands[0] == "" || ferry.Islands[1] == "" || ferry.Islands[0] == ferry.Islands[1] {
+			fail("ferry %q needs a name and two different islands", ferry.Name)
+		}
+		if !validFees(ferry.Fees) {
+			fail("ferry %s needs positive fees for every bus class", ferry.Name)
+		}
+		if ferry.Duration <= 0 {
+			fail("ferry %s needs a positive duration", ferry.Name)
+		}
+	}
+
+	for i, route := range p.Routes {
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(route.Stops) < 2 {
+			fail("route %s needs at least two stops", name)
+			continue
+		}
+		if route.Popularity < 1 || route.Popularity > 100 {
+			fail("route %s popularity must be between 1 and 100", name)
+		}
+		if route.MinBusType != "" && !busTypes[route.MinBusType] {
+			fail("route %s has unknown min_bus_type %q", name, route.MinBusType)
+		}
+		if !routeTypes[route.Type] {
+			fail("route %s has unknown type %q", name, route.Type)
+		}
+		for j, stop := range route.Stops {
+			if stop.City == "" {
+				fail("route %s stop %d has no city", name, j)
+			}
+			if j > 0 && (stop.Distance <= 0 || stop.Duration <= 0 || stop.Fare <= 0) {
+				fail("route %s stop %s needs a positive distance, duration and fare", name, stop.City)
+			}
+		}
+		for _, charge := range route.Charges {
+			switch charge.Kind {
+			case simulation.ChargeToll:
+				if charge.Name == "" || !validFees(charge.Fees) {
+					fail("route %s has a toll without a name or fees", name)
+				}
+				if charge.AltDistance < 0 || charge.AltDuration < 0 || (charge.AltDistance > 0 && charge.AltDuration == 0) {
+					fail("route %s toll %s has an invalid alternative", name, charge.Name)
+				}
+			case simulation.ChargeFerry:
+				if charge.Ferry == "" {
+					fail("route %s has a ferry charge without a ferry", name)
+				}
+			default:
+				fail("route %s has a charge of unknown kind %q", name, charge.Kind)
+			}
+		}
+		if len(route.Geometry) == 1 {
+			fail("route %s geometry needs at least two positions", name)
+		}
+		for j, position := range route.Geometry {
+			if len(position) != 2 || !validCoordinate(position[1], position[0]) {
+				fail("route %s geometry position %d must be a valid [longitude, latitude] pair", name, j)
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validCoordinate(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 && (lat != 0 || lng != 0)
+}
+
+func validFees(fees simulation.ClassFees) bool {
+	return fees.Small > 0 && fees.Medium > 0 && fees.Large > 0
+}
@@ -0,0 +1,350 @@
+package datapack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"bus-manager/data"
+	"bus-manager/internal/geo"
+	"bus-manager/internal/simulation"
+)
+
+// maxGeometryOffsetKm is the allowed gap between a route's geometry endpoints
+// and its terminals.
+const maxGeometryOffsetKm = 25.0
+
+// World is the merged content of every loaded pack.
+type World struct {
+	Packs   []*Pack
+	Cities  []City
+	Ferries []Ferry
+	Routes  []Route // with names, types, bus types and terminals filled in
+	Land    []geo.PolygonFeature
+
+	cities  map[string]City
+	ferries map[string]Ferry
+}
+
+var (
+	currentOnce sync.Once
+	current     *World
+)
+
+// Current returns the world loaded from the bundled packs and the packs in
+// DATA_PACKS_DIR. When the extra packs do not validate, they are reported
+// and the bundled world is used.
+func Current() *World {
+	currentOnce.Do(func() {
+		if dir := os.Getenv("DATA_PACKS_DIR"); dir != "" {
+			world, err := Load(dir)
+			if err == nil {
+				current = world
+				return
+			}
+			log.Printf("Failed to load data packs from %s, using bundled packs: %v", dir, err)
+		}
+
+		var err error
+		current, err = Load("")
+		if err != nil {
+			log.Fatalf("Invalid bundled data packs: %v", err)
+		}
+	})
+	return current
+}
+
+// Load reads the bundled packs and, when dir is set, every *.json pack in it.
+// A pack in dir replaces the bundled pack of the same name.
+func Load(dir string) (*World, error) {
+	byName := map[string]*Pack{}
+
+	bundled, err := fs.Glob(data.Packs, "packs/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range bundled {
+		raw, err := fs.ReadFile(data.Packs, file)
+		if err != nil {
+			return nil, err
+		}
+		pack, err := Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		pack.Source = file
+		byName[pack.Name] = pack
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			pack, err := Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			pack.Source = file
+			byName[pack.Name] = pack
+		}
+	}
+
+	packs := make([]*Pack, 0, len(byName))
+	for _, pack := range byName {
+		packs = append(packs, pack)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return NewWorld(packs...)
+}
+
+// NewWorld merges packs and checks the references between them: route stops
+// must be catalog cities and terminals, routes changing island must take a
+// ferry between the islands, geometry must connect the route terminals and
+// terminals must lie on the land of their island.
+func NewWorld(packs ...*Pack) (*World, error) {
+	w := &World{
+		Packs:   packs,
+		cities:  map[string]City{},
+		ferries: map[string]Ferry{},
+	}
+
+	var errs []error
+	fail := func(pack *Pack, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("data pack %q: %s", pack.Name, fmt.Sprintf(format, args...)))
+	}
+
+	for _, pack := range packs {
+		for _, city := range pack.Cities {
+			if _, ok := w.cities[city.Name]; ok {
+				fail(pack, "city %s is defined by another pack", city.Name)
+				continue
+			}
+			w.cities[city.Name] = city
+			w.Cities = append(w.Cities, city)
+		}
+		for _, ferry := range pack.Ferries {
+			if _, ok := w.ferries[ferry.Name]; ok {
+				fail(pack, "ferry %s is defined by another pack", ferry.Name)
+				continue
+			}
+			w.ferries[ferry.Name] = ferry
+			w.Ferries = append(w.Ferries, ferry)
+		}
+		if len(pack.Land) > 0 {
+			regions, err := geo.ParsePolygonFeatures(pack.Land)
+			if err != nil {
+				fail(pack, "invalid land: %v", err)
+			}
+			for _, region := range regions {
+				if island, _ := region.Properties["island"].(string); island == "" {
+					fail(pack, "land region %v has no island", region.Properties["name"])
+				}
+			}
+			w.Land = append(w.Land, regions...)
+		}
+	}
+
+	// Terminals must be on land wherever the island has land regions
+	landIslands := map[string]bool{}
+	for _, region := range w.Land {
+		island, _ := region.Properties["island"].(string)
+		landIslands[island] = true
+	}
+	for _, pack := range packs {
+		for _, city := range pack.Cities {
+			if !landIslands[city.Island] {
+				continue
+			}
+			for _, terminal := range city.Terminals {
+				if !w.onLand(city.Island, geo.Point{Lat: terminal.Latitude, Lng: terminal.Longitude}) {
+					fail(pack, "terminal %s in %s is not on the land of %s", terminal.Name, city.Name, city.Island)
+				}
+			}
+		}
+	}
+
+	routeNames := map[string]bool{}
+	for _, pack := range packs {
+		for _, route := range pack.Routes {
+			resolved, problems := w.resolveRoute(route)
+			for _, problem := range problems {
+				fail(pack, "route %s: %s", resolved.Name, problem)
+			}
+			if routeNames[resolved.Name] {
+				fail(pack, "route %s is defined twice", resolved.Name)
+			}
+			routeNames[resolved.Name] = true
+			if len(problems) == 0 {
+				w.Routes = append(w.Routes, resolved)
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *World) resolveRoute(route Route) (Route, []string) {
+	var problems []string
+	stops := make([]Stop, len(route.Stops))
+	cities := make([]City, len(route.Stops))
+	copy(stops, route.Stops)
+	route.Stops = stops
+
+	for i := range stops {
+		city, ok := w.cities[stops[i].City]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown city %s", stops[i].City))
+			continue
+		}
+		cities[i] = city
+		if stops[i].Terminal == "" {
+			stops[i].Terminal = city.Terminals[0].Name
+		} else if _, ok := w.Terminal(city.Name, stops[i].Terminal); !ok {
+			problems = append(problems, fmt.Sprintf("unknown terminal %s in %s", stops[i].Terminal, city.Name))
+		}
+	}
+
+	origin, destination := stops[0].City, stops[len(stops)-1].City
+	if route.Name == "" {
+		route.Name = origin + " - " + destination
+	}
+	if route.MinBusType == "" {
+		route.MinBusType = "normal"
+	}
+	if len(problems) > 0 {
+		return route, problems
+	}
+
+	if route.Type == "" {
+		route.Type = "intercity"
+		for _, city := range cities {
+			if city.Province != cities[0].Province {
+				route.Type = "interprovince"
+			}
+		}
+	}
+
+	// Every island change needs a ferry crossing listed among the charges
+	ferries := map[string]int{}
+	for _, charge := range route.Charges {
+		if charge.Kind != simulation.ChargeFerry {
+			continue
+		}
+		if _, ok := w.ferries[charge.Ferry]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown ferry %s", charge.Ferry))
+		}
+		ferries[charge.Ferry]++
+	}
+	for i := 1; i < len(cities); i++ {
+		from, to := cities[i-1].Island, cities[i].Island
+		if from == to {
+			continue
+		}
+		ferry, ok := w.FerryBetween(from, to)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("no ferry between %s and %s", from, to))
+			continue
+		}
+		if ferries[ferry.Name] == 0 {
+			problems = append(problems, fmt.Sprintf("crosses from %s to %s without the %s ferry charge", from, to, ferry.Name))
+			continue
+		}
+		ferries[ferry.Name]--
+	}
+
+	if len(route.Geometry) >= 2 {
+		first := route.Geometry[0]
+		last := route.Geometry[len(route.Geometry)-1]
+		start, _ := w.Terminal(origin, stops[0].Terminal)
+		end, _ := w.Terminal(destination, stops[len(stops)-1].Terminal)
+		if len(first) < 2 || len(last) < 2 ||
+			geo.Haversine(geo.Point{Lat: first[1], Lng: first[0]}, geo.Point{Lat: start.Latitude, Lng: start.Longitude}) > maxGeometryOffsetKm ||
+			geo.Haversine(geo.Point{Lat: last[1], Lng: last[0]}, geo.Point{Lat: end.Latitude, Lng: end.Longitude}) > maxGeometryOffsetKm {
+			problems = append(problems, "geometry does not connect the route terminals")
+		}
+	}
+
+	return route, problems
+}
+
+func (w *World) onLand(island string, p geo.Point) bool {
+	for _, region := range w.Land {
+		if name, _ := region.Properties["island"].(string); name == island && region.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// City returns a catalog city by name.
+func (w *World) City(name string) (City, bool) {
+	city, ok := w.cities[name]
+	return city, ok
+}
+
+// Terminal returns a terminal of a catalog city.
+func (w *World) Terminal(city, name string) (Terminal, bool) {
+	for _, terminal := range w.cities[city].Terminals {
+		if terminal.Name == name {
+			return terminal, true
+		}
+	}
+	return Terminal{}, false
+}
+
+// Ferry returns a crossing by name.
+func (w *World) Ferry(name string) (Ferry, bool) {
+	ferry, ok := w.ferries[name]
+	return ferry, ok
+}
+
+// FerryBetween returns the crossing between two islands, in either direction.
+func (w *World) FerryBetween(from, to string) (Ferry, bool) {
+	for _, f := range w.Ferries {
+		if (f.Islands[0] == from && f.Islands[1] == to) || (f.Islands[0] == to && f.Islands[1] == from) {
+			return f, true
+		}
+	}
+	return Ferry{}, false
+}
+
+// Distance is the length of the route in km.
+func (r Route) Distance() float64 {
+	total := 0.0
+	for _, stop := range r.Stops[1:] {
+		total += stop.Distance
+	}
+	return total
+}
+
+// Duration is the scheduled travel time of the route in minutes.
+func (r Route) Duration() int {
+	total := 0
+	for _, stop := range r.Stops[1:] {
+		total += stop.Duration
+	}
+	return total
+}
+
+// Fare is the end-to-end fare of the route.
+func (r Route) Fare() float64 {
+	total := 0.0
+	for _, stop := range r.Stops[1:] {
+		total += stop.Fare
+	}
+	return total
+}
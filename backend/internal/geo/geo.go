@@ -0,0 +1,91 @@
+package geo
+
+import (
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// Point is a WGS84 coordinate in decimal degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Haversine returns the great-circle distance between a and b in kilometers.
+func Haversine(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// Path is a polyline with precomputed cumulative distances so positions can be
+// interpolated by distance travelled rather than by vertex index.
+type Path struct {
+	Points     []Point
+	cumulative []float64
+}
+
+func NewPath(points []Point) *Path {
+	cumulative := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		cumulative[i] = cumulative[i-1] + Haversine(points[i-1], points[i])
+	}
+	return &Path{Points: points, cumulative: cumulative}
+}
+
+// Length returns the total length of the path in kilometers.
+func (p *Path) Length() float64 {
+	if len(p.cumulative) == 0 {
+		return 0
+	}
+	return p.cumulative[len(p.cumulative)-1]
+}
+
+// PointAt returns the position after travelling fraction (0-1) of the path length.
+func (p *Path) PointAt(fraction float64) Point {
+	if len(p.Points) == 0 {
+		return Point{}
+	}
+	if fraction <= 0 || len(p.Points) == 1 {
+		return p.Points[0]
+	}
+	if fraction >= 1 {
+		return p.Points[len(p.Points)-1]
+	}
+
+	target := p.Length() * fraction
+	for i := 1; i < len(p.Points); i++ {
+		if p.cumulative[i] < target {
+			continue
+		}
+		segment := p.cumulative[i] - p.cumulative[i-1]
+		if segment == 0 {
+			return p.Points[i]
+		}
+		ratio := (target - p.cumulative[i-1]) / segment
+		return Point{
+			Lat: p.Points[i-1].Lat + (p.Points[i].Lat-p.Points[i-1].Lat)*ratio,
+			Lng: p.Points[i-1].Lng + (p.Points[i].Lng-p.Points[i-1].Lng)*ratio,
+		}
+	}
+	return p.Points[len(p.Points)-1]
+}
+
+// PointInRing reports whether p lies inside the closed ring using ray casting.
+func PointInRing(p Point, ring []Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,165 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LineFeature is a named LineString read from a GeoJSON file.
+type LineFeature struct {
+	Properties map[string]interface{}
+	Points     []Point
+}
+
+// PolygonFeature is a named area read from a GeoJSON file.
+type PolygonFeature struct {
+	Properties map[string]interface{}
+	Rings      [][]Point
+}
+
+// Contains reports whether p lies inside any of the feature's rings.
+func (f PolygonFeature) Contains(p Point) bool {
+	for _, ring := range f.Rings {
+		if PointInRing(p, ring) {
+			return true
+		}
+	}
+	return false
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+}
+
+type geoJSONDocument struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+	geoJSONFeature
+}
+
+// ReadLineFeatures loads every LineString feature from a GeoJSON file. The file
+// may be a FeatureCollection or a single Feature.
+func ReadLineFeatures(path string) ([]LineFeature, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLineFeatures(raw)
+}
+
+func ParseLineFeatures(raw []byte) ([]LineFeature, error) {
+	features, err := parseFeatures(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []LineFeature
+	for _, f := range features {
+		if f.Geometry == nil || f.Geometry.Type != "LineString" {
+			continue
+		}
+		var coords [][]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil {
+			return nil, fmt.Errorf("failed to parse LineString coordinates: %w", err)
+		}
+		points, err := toPoints(coords)
+		if err != nil {
+			return nil, err
+		}
+		if len(points) < 2 {
+			return nil, fmt.Errorf("LineString needs at least two positions")
+		}
+		lines = append(lines, LineFeature{Properties: f.Properties, Points: points})
+	}
+	return lines, nil
+}
+
+// ParsePolygonFeatures loads every Polygon and MultiPolygon feature. Each
+// polygon's outer ring is returned as a separate area; holes are ignored.
+func ParsePolygonFeatures(raw []byte) ([]PolygonFeature, error) {
+	features, err := parseFeatures(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var polygons []PolygonFeature
+	for _, f := range features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		var rings [][][]float64
+		switch f.Geometry.Type {
+		case "Polygon":
+			var polygon [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &polygon); err != nil {
+				return nil, fmt.Errorf("failed to parse Polygon coordinates: %w", err)
+			}
+			if len(polygon) > 0 {
+				rings = append(rings, polygon[0])
+			}
+		case "MultiPolygon":
+			var multi [][][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &multi); err != nil {
+				return nil, fmt.Errorf("failed to parse MultiPolygon coordinates: %w", err)
+			}
+			for _, polygon := range multi {
+				if len(polygon) > 0 {
+					rings = append(rings, polygon[0])
+				}
+			}
+		default:
+			continue
+		}
+
+		feature := PolygonFeature{Properties: f.Properties}
+		for _, ring := range rings {
+			points, err := toPoints(ring)
+			if err != nil {
+				return nil, err
+			}
+			if len(points) < 3 {
+				return nil, fmt.Errorf("polygon ring needs at least three positions")
+			}
+			feature.Rings = append(feature.Rings, points)
+		}
+		polygons = append(polygons, feature)
+	}
+	return polygons, nil
+}
+
+func parseFeatures(raw []byte) ([]geoJSONFeature, error) {
+	var doc geoJSONDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	switch doc.Type {
+	case "FeatureCollection":
+		return doc.Features, nil
+	case "Feature":
+		return []geoJSONFeature{doc.geoJSONFeature}, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q", doc.Type)
+	}
+}
+
+func toPoints(coords [][]float64) ([]Point, error) {
+	points := make([]Point, 0, len(coords))
+	for _, c := range coords {
+		if len(c) < 2 {
+			return nil, fmt.Errorf("invalid coordinate %v", c)
+		}
+		// GeoJSON positions are [longitude, latitude]
+		points = append(points, Point{Lat: c[1], Lng: c[0]})
+	}
+	return points, nil
+}
@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Encoded polylines use the Google polyline algorithm with 5 decimal places.
+const polylinePrecision = 1e5
+
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+func EncodePolyline(points []Point) string {
+	var sb strings.Builder
+	var prevLat, prevLng int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * polylinePrecision))
+		lng := int64(math.Round(p.Lng * polylinePrecision))
+		encodeValue(&sb, lat-prevLat)
+		encodeValue(&sb, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return sb.String()
+}
+
+func DecodePolyline(encoded string) ([]Point, error) {
+	var points []Point
+	var lat, lng int64
+	for i := 0; i < len(encoded); {
+		dLat, n, err := decodeValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLng, n, err := decodeValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lng += dLng
+		points = append(points, Point{
+			Lat: float64(lat) / polylinePrecision,
+			Lng: float64(lng) / polylinePrecision,
+		})
+	}
+	return points, nil
+}
+
+func encodeValue(sb *strings.Builder, v int64) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte((0x20 | (u & 0x1f)) + 63))
+		u >>= 5
+	}
+	sb.WriteByte(byte(u + 63))
+}
+
+func decodeValue(s string) (int64, int, error) {
+	var result int64
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		b := int64(s[i]) - 63
+		if b < 0 || shift > 60 {
+			return 0, 0, ErrInvalidPolyline
+		}
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), i + 1, nil
+			}
+			return result >> 1, i + 1, nil
+		}
+	}
+	return 0, 0, ErrInvalidPolyline
+}
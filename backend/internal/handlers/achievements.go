@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"bus-manager/data"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	achievementsOnce sync.Once
+	achievements     []simulation.Achievement
+)
+
+// gameAchievements returns the achievement and quest definitions, read from
+// ACHIEVEMENTS_FILE when set and from the bundled configuration otherwise.
+func gameAchievements() []simulation.Achievement {
+	achievementsOnce.Do(func() {
+		if path := os.Getenv("ACHIEVEMENTS_FILE"); path != "" {
+			raw, err := os.ReadFile(path)
+			if err == nil {
+				achievements, err = simulation.ParseAchievements(raw)
+			}
+			if err == nil {
+				return
+			}
+			log.Printf("Failed to load achievements from %s, using defaults: %v", path, err)
+		}
+
+		var err error
+		achievements, err = simulation.ParseAchievements(data.Achievements)
+		if err != nil {
+			log.Fatalf("Invalid bundled achievements: %v", err)
+		}
+	})
+	return achievements
+}
+
+// AchievementStatus is an achievement definition with the company's progress on it.
+type AchievementStatus struct {
+	simulation.Achievement
+	Progress    float64    `json:"progress"`
+	Completed   bool       `json:"completed"`
+	Available   bool       `json:"available"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func (h *GameHandler) GetAchievements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var rows []models.AchievementProgress
+	if err := h.db.Where("company_id = ?", company.ID).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+	progress := make(map[string]models.AchievementProgress, len(rows))
+	for _, row := range rows {
+		progress[row.AchievementID] = row
+	}
+
+	statuses := make([]AchievementStatus, 0, len(gameAchievements()))
+	for _, a := range gameAchievements() {
+		row := progress[a.ID]
+		statuses = append(statuses, AchievementStatus{
+			Achievement: a,
+			Progress:    row.Progress,
+			Completed:   row.Completed,
+			Available:   a.Requires == "" || progress[a.Requires].Completed,
+			CompletedAt: row.CompletedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+// PublishEvent advances the company's achievements and quests listening to the
+// event, grants the rewards of those it completes and notifies the owner.
+func (h *WSHub) PublishEvent(companyID uint, event simulation.GameEvent) {
+	var company models.Company
+	if err := h.db.First(&company, companyID).Error; err != nil {
+		return
+	}
+
+	var rows []models.AchievementProgress
+	if err := h.db.Where("company_id = ?", companyID).Find(&rows).Error; err != nil {
+		log.Printf("Failed to fetch achievements for company %d: %v", companyID, err)
+		return
+	}
+	progress := make(map[string]models.AchievementProgress, len(rows))
+	for _, row := range rows {
+		progress[row.AchievementID] = row
+	}
+
+	// Quests unlocked by this event start counting from the next one
+	for _, a := range gameAchievements() {
+		row := progress[a.ID]
+		if row.Completed || (a.Requires != "" && !progress[a.Requires].Completed) {
+			continue
+		}
+		if next, counted := a.Progress(row.Progress, event); !counted || next == row.Progress {
+			continue
+		}
+
+		completed, err := h.advanceAchievement(&company, a, event)
+		if err != nil {
+			log.Printf("Failed to advance achievement %s for company %d: %v", a.ID, companyID, err)
+			continue
+		}
+		if completed {
+			h.completeAchievement(&company, a)
+		}
+	}
+}
+
+// advanceAchievement applies the event to the company's progress on the
+// achievement under a row lock, so concurrent events for the company count
+// one after another, and pays the reward through the ledger when the event
+// completes it. It reports whether this event completed the achievement.
+func (h *WSHub) advanceAchievement(company *models.Company, a simulation.Achievement, event simulation.GameEvent) (bool, error) {
+	completed := false
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		row := models.AchievementProgress{CompanyID: company.ID, AchievementID: a.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("company_id = ? AND achievement_id = ?", company.ID, a.ID).First(&row).Error; err != nil {
+			return err
+		}
+		if row.Completed {
+			return nil
+		}
+		next, counted := a.Progress(row.Progress, event)
+		if !counted || next == row.Progress {
+			return nil
+		}
+
+		updates := map[string]interface{}{"progress": next}
+		if next >= a.Target {
+			now := simulation.Now()
+			updates["completed"] = true
+			updates["completed_at"] = &now
+		}
+		result := tx.Model(&models.AchievementProgress{}).
+			Where("id = ? AND completed = ?", row.ID, false).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 || next < a.Target {
+			return nil
+		}
+
+		completed = true
+		if a.Reward.Money > 0 {
+			return recordTransaction(tx, company, "reward", "Achievement reward: "+a.Name, a.Reward.Money)
+		}
+		return nil
+	})
+	return completed && err == nil, err
+}
+
+// completeAchievement tells the company owner about a completed achievement
+// and the quests it makes available, and awards its experience.
+func (h *WSHub) completeAchievement(company *models.Company, a simulation.Achievement) {
+	h.broadcast <- WSMessage{
+		Type:   "achievement_unlocked",
+		Data:   a,
+		UserID: company.UserID,
+	}
+
+	// Let the owner know about quests that just became available
+	for _, quest := range gameAchievements() {
+		if quest.Requires == a.ID {
+			h.broadcast <- WSMessage{
+				Type:   "quest_available",
+				Data:   quest,
+				UserID: company.UserID,
+			}
+		}
+	}
+
+	if a.Reward.Experience > 0 {
+		h.awardExperience(company.ID, a.Reward.Experience)
+	}
+}
+
+// awardExperience adds experience to a company and publishes the level it
+// reaches when it levels up.
+func (h *WSHub) awardExperience(companyID uint, xp int) {
+	company, leveledUp, err := awardExperience(h.db, companyID, xp)
+	if err != nil {
+		log.Printf("Failed to award experience to company %d: %v", companyID, err)
+		return
+	}
+	if leveledUp {
+		log.Printf("Company %d reached level %d", company.ID, company.Level)
+		h.PublishEvent(company.ID, simulation.GameEvent{
+			Type:   simulation.EventLevelReached,
+			Values: map[string]float64{"level": float64(company.Level)},
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actionError is a game rule refusing a company's action. Handlers answer it
+// with its status and message; AI companies, which take the same actions
+// outside of a request, log it.
+type actionError struct {
+	status  int
+	message string
+}
+
+func (e *actionError) Error() string {
+	return e.message
+}
+
+func refuse(status int, message string) error {
+	return &actionError{status: status, message: message}
+}
+
+// refusePayment turns errInsufficientFunds into a refusal with message and
+// passes any other error through.
+func refusePayment(err error, message string) error {
+	if errors.Is(err, errInsufficientFunds) {
+		return refuse(http.StatusBadRequest, message)
+	}
+	return err
+}
+
+// respondActionError answers a request whose action failed: refusals get their
+// own status and message, anything else is a server error with message.
+func respondActionError(c *gin.Context, err error, message string) {
+	var refused *actionError
+	if errors.As(err, &refused) {
+		c.JSON(refused.status, gin.H{"error": refused.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bus-manager/data"
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxAICompanies = 3
+	aiEmailDomain         = "ai.bus-manager.local"
+	aiLoanWeeks           = 26
+	// aiPassword is not a bcrypt hash, so nobody can log in as an AI company.
+	aiPassword = "!"
+)
+
+var (
+	aiStrategiesOnce sync.Once
+	aiStrategySet    *simulation.AIStrategies
+
+	maxAICompaniesOnce sync.Once
+	maxAICompaniesSet  int
+)
+
+// aiStrategies returns the strategies AI companies play by, read from
+// AI_STRATEGIES_FILE when set and from the bundled configuration otherwise.
+func aiStrategies() *simulation.AIStrategies {
+	aiStrategiesOnce.Do(func() {
+		if path := os.Getenv("AI_STRATEGIES_FILE"); path != "" {
+			raw, err := os.ReadFile(path)
+			if err == nil {
+				aiStrategySet, err = simulation.ParseAIStrategies(raw)
+			}
+			if err == nil {
+				return
+			}
+			log.Printf("Failed to load AI strategies from %s, using defaults: %v", path, err)
+		}
+
+		var err error
+		aiStrategySet, err = simulation.ParseAIStrategies(data.AIStrategies)
+		if err != nil {
+			log.Fatalf("Invalid bundled AI strategies: %v", err)
+		}
+	})
+	return aiStrategySet
+}
+
+// maxAICompanies is how many AI companies the world runs, from AI_COMPANIES.
+// Zero disables them.
+func maxAICompanies() int {
+	maxAICompaniesOnce.Do(func() {
+		maxAICompaniesSet = defaultMaxAICompanies
+		if raw := os.Getenv("AI_COMPANIES"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				log.Printf("Invalid AI_COMPANIES %q, using %d", raw, defaultMaxAICompanies)
+				return
+			}
+			maxAICompaniesSet = n
+		}
+	})
+	return maxAICompaniesSet
+}
+
+type Competitor struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Strategy   string `json:"strategy"`
+	Level      int    `json:"level"`
+	Reputation int    `json:"reputation"`
+	Buses      int64  `json:"buses"`
+	Routes     int64  `json:"routes"`
+}
+
+func (h *GameHandler) GetCompetitors(c *gin.Context) {
+	var companies []models.Company
+	if err := h.db.Where("ai_strategy <> ?", "").Order("id").Find(&companies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch competitors"})
+		return
+	}
+
+	competitors := make([]Competitor, 0, len(companies))
+	for _, company := range companies {
+		competitor := Competitor{
+			ID:         company.ID,
+			Name:       company.Name,
+			Strategy:   company.AIStrategy,
+			Level:      company.Level,
+			Reputation: company.Reputation,
+		}
+		if strategy, ok := aiStrategies().Find(company.AIStrategy); ok {
+			competitor.Strategy = strategy.Name
+		}
+		h.db.Model(&models.Bus{}).Where("company_id = ?", company.ID).Count(&competitor.Buses)
+		h.db.Model(&models.RoutePermit{}).Where("company_id = ? AND status = ?", company.ID, "active").Count(&competitor.Routes)
+		competitors = append(competitors, competitor)
+	}
+
+	c.JSON(http.StatusOK, competitors)
+}
+
+// processAICompanies tops the world up to its AI companies and lets every AI
+// company whose strategy is due take its turn.
+func (w *World) processAICompanies(now time.Time) {
+	if err := w.spawnAICompanies(); err != nil {
+		log.Printf("Failed to create AI companies: %v", err)
+	}
+
+	var companies []models.Company
+	if err := w.db.Where("ai_strategy <> ?", "").Find(&companies).Error; err != nil {
+		log.Printf("Failed to fetch AI companies: %v", err)
+		return
+	}
+
+	for _, company := range companies {
+		strategy, ok := aiStrategies().Find(company.AIStrategy)
+		if !ok || now.Hour()%strategy.ActionIntervalHours != 0 {
+			continue
+		}
+		player := &aiPlayer{game: w.game, companyID: company.ID, userID: company.UserID, strategy: strategy}
+		player.takeTurn()
+	}
+}
+
+// spawnAICompanies creates AI companies until the world runs AI_COMPANIES of
+// them, taking turns between the strategies.
+func (w *World) spawnAICompanies() error {
+	var count int64
+	if err := w.db.Model(&models.Company{}).Where("ai_strategy <> ?", "").Count(&count).Error; err != nil {
+		return err
+	}
+
+	strategies := aiStrategies().Strategies
+	for n := int(count); n < maxAICompanies(); n++ {
+		strategy := strategies[n%len(strategies)]
+		name := strategy.CompanyName(n / len(strategies))
+		slug := strings.ReplaceAll(strings.ToLower(name), " ", "-")
+
+		// The user, the company and its funding are created together, so a
+		// failure never leaves a half-made AI company behind
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			user := models.User{
+				Email:    slug + "@" + aiEmailDomain,
+				Username: slug,
+				Password: aiPassword,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return fmt.Errorf("failed to create user for %s: %w", name, err)
+			}
+
+			// AI companies start like players and receive the rest of their
+			// strategy's capital from investors
+			company, err := createCompany(tx, user.ID, name)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", name, err)
+			}
+			if err := tx.Model(&company).Update("ai_strategy", strategy.Strategy).Error; err != nil {
+				return err
+			}
+			if funding := strategy.StartingCapital - company.Money; funding > 0 {
+				return recordTransaction(tx, &company, "income", "Investor capital", funding)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("AI company %s joined with the %s strategy", name, strategy.Name)
+	}
+	return nil
+}
+
+// aiPlayer plays an AI company through the same game actions as the players,
+// so it is bound by the same rules, prices and ledger.
+type aiPlayer struct {
+	game      *GameHandler
+	companyID uint
+	userID    uint
+	strategy  simulation.AIStrategy
+}
+
+func (p *aiPlayer) company() (models.Company, error) {
+	var company models.Company
+	err := p.game.db.First(&company, p.companyID).Error
+	return company, err
+}
+
+// spendable is what the company may spend without breaking into its reserve.
+func (p *aiPlayer) spendable() float64 {
+	company, err := p.company()
+	if err != nil {
+		return 0
+	}
+	return company.Money - p.strategy.CashReserve
+}
+
+// takeTurn renews the fleet, expands the network and schedules its routes.
+// A step that fails is logged and the turn moves on.
+func (p *aiPlayer) takeTurn() {
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"sell worn buses", p.sellWornBuses},
+		{"set up depot", p.setUpDepot},
+		{"renew permits", p.renewPermits},
+		{"expand", p.expand},
+		{"set fares", p.setFares},
+		{"open timetables", p.openTimetables},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			log.Printf("AI company %d failed to %s: %v", p.companyID, step.name, err)
+		}
+	}
+}
+
+func (p *aiPlayer) sellWornBuses() error {
+	var buses []models.Bus
+	p.game.db.Where("company_id = ? AND status = ? AND condition < ?", p.companyID, "available", p.strategy.MinCondition).Find(&buses)
+	if len(buses) == 0 {
+		return nil
+	}
+	company, err := p.company()
+	if err != nil {
+		return err
+	}
+	for _, bus := range buses {
+		if _, _, err := p.game.disposeBus(&company, bus, "dealer"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setUpDepot builds the first depot, near a home city that depends on the
+// company, then its fuel station, and raises the depot level once it is full.
+func (p *aiPlayer) setUpDepot() error {
+	company, err := p.company()
+	if err != nil {
+		return err
+	}
+
+	var depot models.Depot
+	if err := p.game.db.Where("company_id = ?", p.companyID).Order("id").First(&depot).Error; err != nil {
+		var cities []models.City
+		p.game.db.Order("name").Find(&cities)
+		for i := range cities {
+			city := cities[(int(p.companyID)+i)%len(cities)]
+			quote := quoteLand(p.game.db, geo.Point{Lat: city.Latitude, Lng: city.Longitude})
+			if !quote.Allowed {
+				continue
+			}
+			if quote.Price > p.spendable() {
+				return nil
+			}
+			_, err := p.game.buildDepot(&company, CreateDepotRequest{
+				Name:      "Pool " + city.Name,
+				Latitude:  city.Latitude,
+				Longitude: city.Longitude,
+			})
+			return err
+		}
+		return errors.New("no city has land for a depot")
+	}
+
+	if !depot.HasFuelStation {
+		if depotFuelStationCost > p.spendable() {
+			return nil
+		}
+		return p.game.upgradeDepot(&company, &depot, "fuel_station")
+	}
+
+	if depot.CurrentBuses >= depot.Capacity && depot.Level < depotMaxLevel && depot.CurrentBuses < p.strategy.MaxBuses {
+		if depotLevelUpgradeCost*float64(depot.Level) > p.spendable() {
+			return nil
+		}
+		return p.game.upgradeDepot(&company, &depot, "level")
+	}
+	return nil
+}
+
+func (p *aiPlayer) renewPermits() error {
+	var permits []models.RoutePermit
+	renewBy := simulation.Now().Add(permitRenewalDays * simulation.GameDay)
+	p.game.db.Where("company_id = ? AND status = ? AND expires_at <= ?", p.companyID, "active", renewBy).Preload("Route").Find(&permits)
+	for _, permit := range permits {
+		if permitPrice(permit.Route) > p.spendable() {
+			continue
+		}
+		company, err := p.company()
+		if err != nil {
+			return err
+		}
+		if err := p.game.renewPermit(&company, &permit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand buys a permit for the best route the strategy can afford, or a bus
+// while the fleet is short of the strategy's target. Strategies that borrow
+// take a loan when they run out of money to grow.
+func (p *aiPlayer) expand() error {
+	company, err := p.company()
+	if err != nil {
+		return err
+	}
+
+	var permits int64
+	p.game.db.Model(&models.RoutePermit{}).Where("company_id = ? AND status = ?", p.companyID, "active").Count(&permits)
+	var buses int64
+	p.game.db.Model(&models.Bus{}).Where("company_id = ?", p.companyID).Count(&buses)
+
+	short := false
+	if int(buses) < p.strategy.FleetTarget(int(permits)) {
+		model, ok := p.busModel(company)
+		if !ok {
+			return nil
+		}
+		if model.Price <= p.spendable() {
+			_, err := p.game.buyBus(&company, CreateBusRequest{
+				Name:        fmt.Sprintf("%s %02d", company.Name, buses+1),
+				Model:       model.Model,
+				ServiceType: p.strategy.ServiceType,
+			})
+			return err
+		}
+		short = true
+	} else if int(permits) < p.strategy.MaxRoutes {
+		route, ok := p.bestRoute(company)
+		if !ok {
+			return nil
+		}
+		if permitPrice(route) <= p.spendable() {
+			_, err := p.game.buyPermit(&company, route)
+			return err
+		}
+		short = true
+	}
+
+	if short && p.strategy.Borrow {
+		return p.borrow(company)
+	}
+	return nil
+}
+
+// busModel is the first catalog bus of the strategy unlocked at the company's level.
+func (p *aiPlayer) busModel(company models.Company) (simulation.DieselBusModel, bool) {
+	for _, name := range p.strategy.Buses {
+		model, _ := simulation.FindDieselBusModel(name)
+		if level, known := gameProgression().BusTypeLevel(model.Type); known && level <= company.Level {
+			return model, true
+		}
+	}
+	return simulation.DieselBusModel{}, false
+}
+
+// bestRoute is the route with a free permit slot the strategy ranks highest
+// among those the company may operate.
+func (p *aiPlayer) bestRoute(company models.Company) (models.Route, bool) {
+	var routes []models.Route
+	query := p.game.db.Where("status = ? AND min_reputation <= ?", "approved", company.Reputation).
+		Where("id NOT IN (SELECT route_id FROM route_permits WHERE company_id = ? AND status = ?)", company.ID, "active").
+		Where("permit_slots > (SELECT COUNT(*) FROM route_permits WHERE route_permits.route_id = routes.id AND status = ?)", "active")
+	if !gameProgression().Unlocks(company.Level).InterprovinceRoutes {
+		query = query.Where("type <> ?", "interprovince")
+	}
+	if err := query.Find(&routes).Error; err != nil || len(routes) == 0 {
+		return models.Route{}, false
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return p.strategy.RouteScore(routes[i].Popularity, routes[i].Distance) > p.strategy.RouteScore(routes[j].Popularity, routes[j].Distance)
+	})
+	return routes[0], true
+}
+
+// borrow takes the largest loan the bank offers, one loan at a time.
+func (p *aiPlayer) borrow(company models.Company) error {
+	var loans int64
+	p.game.db.Model(&models.Loan{}).Where("company_id = ? AND status = ?", p.companyID, "active").Count(&loans)
+	if loans > 0 {
+		return nil
+	}
+
+	maxAmount, _ := simulation.LoanLimit(companyCredit(p.game.db, company))
+	if maxAmount < loanMinAmount {
+		return nil
+	}
+	_, err := p.game.takeLoan(&company, maxAmount, aiLoanWeeks)
+	return err
+}
+
+// setFares prices every permitted route at the strategy's fare.
+func (p *aiPlayer) setFares() error {
+	var permits []models.RoutePermit
+	p.game.db.Where("company_id = ? AND status = ? AND fare_multiplier <> ?", p.companyID, "active", p.strategy.FareMultiplier).Find(&permits)
+	if len(permits) == 0 {
+		return nil
+	}
+	company, err := p.company()
+	if err != nil {
+		return err
+	}
+	for _, permit := range permits {
+		if err := p.game.setFare(&company, &permit, p.strategy.FareMultiplier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openTimetables schedules the strategy's daily departures on every permitted
+// route; the world then runs them with whichever buses are idle.
+func (p *aiPlayer) openTimetables() error {
+	var permits []models.RoutePermit
+	p.game.db.Where("company_id = ? AND status = ?", p.companyID, "active").Find(&permits)
+	if len(permits) == 0 {
+		return nil
+	}
+
+	var timetables []models.Timetable
+	p.game.db.Where("company_id = ?", p.companyID).Find(&timetables)
+	scheduled := make(map[[2]uint]bool, len(timetables))
+	for _, timetable := range timetables {
+		scheduled[[2]uint{timetable.RouteID, uint(timetable.DepartureHour)}] = true
+	}
+
+	company, err := p.company()
+	if err != nil {
+		return err
+	}
+	for _, permit := range permits {
+		for _, hour := range p.strategy.DepartureHours() {
+			if scheduled[[2]uint{permit.RouteID, uint(hour)}] {
+				continue
+			}
+			if _, err := p.game.openTimetable(&company, CreateTimetableRequest{RouteID: permit.RouteID, DepartureHour: hour}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Dealers buy used buses below their market value.
+const dealerPriceRatio = 0.8
+
+func (h *GameHandler) GetBusValuation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	marketValue := busMarketValue(bus)
+	c.JSON(http.StatusOK, gin.H{
+		"bus_id":       bus.ID,
+		"market_value": marketValue,
+		"dealer_price": math.Round(marketValue * dealerPriceRatio),
+		"scrap_value":  math.Round(simulation.ScrapValue(bus.PurchasePrice)),
+	})
+}
+
+func (h *GameHandler) SellBus(c *gin.Context) {
+	h.disposeOfBus(c, "dealer")
+}
+
+func (h *GameHandler) ScrapBus(c *gin.Context) {
+	h.disposeOfBus(c, "scrap")
+}
+
+// disposeOfBus sells a bus to a dealer or scraps it, removing it from the fleet.
+func (h *GameHandler) disposeOfBus(c *gin.Context, method string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	price, description, err := h.disposeBus(&company, bus, method)
+	if err != nil {
+		respondActionError(c, err, "Failed to remove bus")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": description,
+		"price":   price,
+	})
+}
+
+// disposeBus sells a company bus to a dealer or scraps it and returns the
+// price it fetched with the ledger description.
+func (h *GameHandler) disposeBus(company *models.Company, bus models.Bus, method string) (float64, string, error) {
+	var price float64
+	var description string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the bus so concurrent sales cannot both pay out
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND company_id = ?", bus.ID, company.ID).First(&bus).Error; err != nil {
+			return refuse(http.StatusNotFound, "Bus not found or not owned by company")
+		}
+		if bus.Status != "available" && bus.Status != "maintenance" {
+			return refuse(http.StatusBadRequest, "Bus must be in the depot to be sold or scrapped")
+		}
+
+		price = math.Round(simulation.ScrapValue(bus.PurchasePrice))
+		description = "Scrapped bus: " + bus.Name
+		if method == "dealer" {
+			price = math.Round(busMarketValue(bus) * dealerPriceRatio)
+			description = "Sold bus to dealer: " + bus.Name
+		}
+
+		if err := removeBusFromFleet(tx, bus); err != nil {
+			return err
+		}
+		return recordTransaction(tx, company, "sale", description, price)
+	})
+	return price, description, err
+}
+
+// busMarketValue depreciates a bus by age on the game clock, mileage and condition.
+func busMarketValue(bus models.Bus) float64 {
+	purchasedAt := bus.PurchasedAt
+	if purchasedAt.IsZero() {
+		purchasedAt = simulation.GameStart
+	}
+	ageDays := simulation.Now().Sub(purchasedAt).Hours() / 24
+	return math.Round(simulation.BusValue(bus.PurchasePrice, ageDays, bus.Odometer, bus.Condition))
+}
+
+func removeBusFromFleet(tx *gorm.DB, bus models.Bus) error {
+	if err := endBusPolicies(tx, bus.ID); err != nil {
+		return err
+	}
+	// Its departures fall back to the company's other idle buses
+	if err := tx.Model(&models.Timetable{}).Where("bus_id = ?", bus.ID).Update("bus_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Depot{}).Where("id = ?", bus.DepotID).
+		Update("current_buses", gorm.Expr("current_buses - 1")).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&bus).Error
+}
+
+// returnToDepot parks a bus back at its depot after driving distance km on
+// energy liters or kWh and losing damage condition. Depot facilities service
+// it before its next departure: diesel buses fill up at the fuel station when
+// the company can pay for the diesel, and electric buses plug in when a
+// charger is free; otherwise they wait for the player to refuel (RefuelBus)
+// or charge (ChargeBus) them. The bus must have its Depot loaded.
+func returnToDepot(db *gorm.DB, bus *models.Bus, energy, distance, damage float64) {
+	bus.Status = "available"
+	bus.CurrentFuel = math.Max(0, bus.CurrentFuel-energy)
+	bus.Odometer += distance
+	bus.Condition = math.Max(0, bus.Condition-damage)
+
+	if bus.Depot.HasWorkshop {
+		bus.Condition = math.Min(100, bus.Condition+workshopConditionBoost)
+	}
+	if err := db.Omit(clause.Associations).Save(bus).Error; err != nil {
+		log.Printf("Failed to return bus %d to its depot: %v", bus.ID, err)
+		return
+	}
+
+	if bus.Depot.HasFuelStation && bus.Powertrain != simulation.PowertrainElectric {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return refuelBus(tx, bus)
+		})
+		if err != nil && !errors.Is(err, errInsufficientFunds) && !errors.Is(err, errBusNotAvailable) {
+			log.Printf("Failed to refuel bus %d: %v", bus.ID, err)
+		}
+	}
+
+	if bus.Powertrain == simulation.PowertrainElectric && bus.Depot.Chargers > 0 {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return startCharging(tx, bus, simulation.Now())
+		})
+		if err != nil && !errors.Is(err, errBatteryFull) && !errors.Is(err, errNoFreeCharger) &&
+			!errors.Is(err, errNoChargingPower) && !errors.Is(err, errChargingUnaffordable) && !errors.Is(err, errBusNotAvailable) {
+			log.Printf("Failed to start charging bus %d: %v", bus.ID, err)
+		}
+	}
+}
+
+func (h *GameHandler) RefuelBus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	if bus.Powertrain == simulation.PowertrainElectric {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Electric buses are charged, not refuelled"})
+		return
+	}
+	if bus.CurrentFuel >= bus.FuelCapacity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tank is already full"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return refuelBus(tx, &bus)
+	})
+	switch {
+	case errors.Is(err, errBusNotAvailable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is not available"})
+		return
+	case errors.Is(err, errInsufficientFunds):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient funds for diesel"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refuel bus"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bus)
+}
+
+// refuelBus fills the tank of an available diesel bus and bills the diesel.
+// The bus is locked so concurrent refuels are billed once.
+func refuelBus(tx *gorm.DB, bus *models.Bus) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", bus.ID, "available").First(bus).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errBusNotAvailable
+		}
+		return err
+	}
+	liters := bus.FuelCapacity - bus.CurrentFuel
+	if liters <= 0 {
+		return nil
+	}
+
+	var company models.Company
+	if err := tx.First(&company, bus.CompanyID).Error; err != nil {
+		return err
+	}
+	cost := math.Round(liters * simulation.DieselPrice)
+	if err := spendFunds(tx, &company, "fuel", fmt.Sprintf("Refuelling %s: %.0f L", bus.Name, liters), cost); err != nil {
+		return err
+	}
+
+	bus.CurrentFuel = bus.FuelCapacity
+	return tx.Model(bus).Update("current_fuel", bus.CurrentFuel).Error
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errBatteryFull          = errors.New("battery is already full")
+	errNoFreeCharger        = errors.New("no free charging station at the depot")
+	errNoChargingPower      = errors.New("the depot grid connection is at capacity")
+	errChargingUnaffordable = errors.New("insufficient funds for electricity")
+	errBusNotAvailable      = errors.New("bus is not available")
+)
+
+// BusModel is a model in the bus catalog with its range and unlock level.
+type BusModel struct {
+	Model       string  `json:"model"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Capacity    int     `json:"capacity"`
+	Price       float64 `json:"price"` // IDR
+	Powertrain  string  `json:"powertrain"`
+	BatteryKWh  float64 `json:"battery_kwh,omitempty"`
+	Consumption float64 `json:"consumption,omitempty"` // kWh per km empty with the AC off
+	MaxChargeKW float64 `json:"max_charge_kw,omitempty"`
+	Range       float64 `json:"range"` // km on a full tank or battery, empty with the AC off
+	UnlockLevel int     `json:"unlock_level"`
+}
+
+func (h *GameHandler) GetBusModels(c *gin.Context) {
+	catalog := make([]BusModel, 0, len(simulation.DieselBusModels)+len(simulation.ElectricBusModels))
+	for _, model := range simulation.DieselBusModels {
+		level, _ := gameProgression().BusTypeLevel(model.Type)
+		catalog = append(catalog, BusModel{
+			Model:       model.Model,
+			Name:        model.Name,
+			Type:        model.Type,
+			Capacity:    model.Capacity,
+			Price:       model.Price,
+			Powertrain:  simulation.PowertrainDiesel,
+			Range:       simulation.DieselRangeKm,
+			UnlockLevel: level,
+		})
+	}
+	for _, model := range simulation.ElectricBusModels {
+		level, _ := gameProgression().BusTypeLevel(model.Type)
+		catalog = append(catalog, BusModel{
+			Model:       model.Model,
+			Name:        model.Name,
+			Type:        model.Type,
+			Capacity:    model.Capacity,
+			Price:       model.Price,
+			Powertrain:  simulation.PowertrainElectric,
+			BatteryKWh:  model.BatteryKWh,
+			Consumption: model.Consumption,
+			MaxChargeKW: model.MaxChargeKW,
+			Range:       model.Range(),
+			UnlockLevel: level,
+		})
+	}
+
+	c.JSON(http.StatusOK, catalog)
+}
+
+func (h *GameHandler) ChargeBus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	if bus.Powertrain != simulation.PowertrainElectric {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only electric buses can be charged"})
+		return
+	}
+	if bus.Status != "available" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is not available"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return startCharging(tx, &bus, simulation.Now())
+	})
+	switch {
+	case errors.Is(err, errBatteryFull), errors.Is(err, errNoFreeCharger), errors.Is(err, errNoChargingPower), errors.Is(err, errChargingUnaffordable), errors.Is(err, errBusNotAvailable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start charging"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bus)
+}
+
+// busAirConditioned reports whether a bus runs its air conditioning; every
+// service above economy is air conditioned.
+func busAirConditioned(bus models.Bus) bool {
+	return bus.ServiceType != "economy"
+}
+
+// tripEnergy is the diesel in liters or the electricity in kWh a bus uses to
+// drive distance km with passengers on board.
+func tripEnergy(bus models.Bus, distance float64, passengers int) float64 {
+	if bus.Powertrain != simulation.PowertrainElectric {
+		return distance / simulation.DieselKmPerLiter
+	}
+	return distance * simulation.EnergyPerKm(bus.Consumption, passengers, bus.Capacity, busAirConditioned(bus))
+}
+
+// startCharging plugs an available electric bus into a free charging station
+// at its depot and bills the electricity. Charging power is shared under the
+// depot's grid capacity, so the depot is locked while its chargers are
+// counted, and the bus cannot be dispatched until it is full.
+func startCharging(tx *gorm.DB, bus *models.Bus, now time.Time) error {
+	energy := bus.FuelCapacity - bus.CurrentFuel
+	if energy <= 0 {
+		return errBatteryFull
+	}
+
+	var depot models.Depot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&depot, bus.DepotID).Error; err != nil {
+		return err
+	}
+
+	var charging []models.Bus
+	if err := tx.Where("depot_id = ? AND status = ?", depot.ID, "charging").Find(&charging).Error; err != nil {
+		return err
+	}
+	if len(charging) >= depot.Chargers {
+		return errNoFreeCharger
+	}
+
+	power := simulation.ChargerPowerKW
+	if model, ok := simulation.FindElectricBusModel(bus.Model); ok {
+		power = math.Min(power, model.MaxChargeKW)
+	}
+	available := depot.PowerCapacity
+	for _, other := range charging {
+		available -= other.ChargingPower
+	}
+	power = math.Min(power, available)
+	if power <= 0 {
+		return errNoChargingPower
+	}
+
+	var company models.Company
+	if err := tx.First(&company, bus.CompanyID).Error; err != nil {
+		return err
+	}
+	cost := math.Round(energy * simulation.ElectricityTariff)
+	if err := spendFunds(tx, &company, "electricity", fmt.Sprintf("Charging %s: %.0f kWh", bus.Name, energy), cost); err != nil {
+		if errors.Is(err, errInsufficientFunds) {
+			return errChargingUnaffordable
+		}
+		return err
+	}
+
+	// A bus dispatched meanwhile stays on its trip and the bill is rolled back
+	until := now.Add(simulation.ChargeDuration(energy, power))
+	result := tx.Model(&models.Bus{}).Where("id = ? AND status = ?", bus.ID, "available").Updates(map[string]interface{}{
+		"status":         "charging",
+		"charging_until": &until,
+		"charging_power": power,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errBusNotAvailable
+	}
+	bus.Status = "charging"
+	bus.ChargingUntil = &until
+	bus.ChargingPower = power
+	return nil
+}
+
+// finishCharging unplugs a bus whose charge is complete.
+func finishCharging(db *gorm.DB, bus *models.Bus) error {
+	bus.Status = "available"
+	bus.CurrentFuel = bus.FuelCapacity
+	bus.ChargingUntil = nil
+	bus.ChargingPower = 0
+	return db.Model(bus).Updates(map[string]interface{}{
+		"status":         bus.Status,
+		"current_fuel":   bus.CurrentFuel,
+		"charging_until": nil,
+		"charging_power": 0,
+	}).Error
+}
+
+// processCharging makes buses whose charge completed available again.
+func (w *World) processCharging(now time.Time) {
+	var buses []models.Bus
+	if err := w.db.Where("status = ? AND charging_until <= ?", "charging", now).Find(&buses).Error; err != nil {
+		log.Printf("Failed to fetch charging buses: %v", err)
+		return
+	}
+	for i := range buses {
+		if err := finishCharging(w.db, &buses[i]); err != nil {
+			log.Printf("Failed to finish charging bus %d: %v", buses[i].ID, err)
+		}
+	}
+}
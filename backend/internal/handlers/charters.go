@@ -0,0 +1,403 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxOpenCharters     = 5 // jobs on the board at once
+	maxBookedCharters   = 3 // per company
+	charterDamagePer100 = 0.5
+)
+
+var errCharterNotBooked = errors.New("charter job is no longer booked")
+
+type DispatchCharterRequest struct {
+	BusID uint `json:"bus_id" binding:"required"`
+}
+
+func (h *GameHandler) GetCharters(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var board []models.CharterJob
+	if err := h.db.Where("status = ? AND offer_expires_at > ?", "open", simulation.Now()).
+		Order("starts_at").Find(&board).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charter jobs"})
+		return
+	}
+
+	var jobs []models.CharterJob
+	if err := h.db.Where("company_id = ?", company.ID).Order("starts_at DESC").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charter jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"board": board,
+		"jobs":  jobs,
+	})
+}
+
+func (h *GameHandler) BookCharter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	// Bookings by the same company are serialized on its row, so the cap
+	// holds when several requests race
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&company, company.ID).Error; err != nil {
+			return err
+		}
+
+		var booked int64
+		if err := tx.Model(&models.CharterJob{}).Where("company_id = ? AND status IN ?", company.ID, []string{"booked", "in_progress"}).Count(&booked).Error; err != nil {
+			return err
+		}
+		if booked >= maxBookedCharters {
+			return refuse(http.StatusBadRequest, fmt.Sprintf("You can hold at most %d charter jobs at a time", maxBookedCharters))
+		}
+
+		result := tx.Model(&models.CharterJob{}).
+			Where("id = ? AND status = ? AND offer_expires_at > ?", c.Param("id"), "open", simulation.Now()).
+			Updates(map[string]interface{}{"company_id": company.ID, "status": "booked"})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return refuse(http.StatusNotFound, "Charter job not found or already booked")
+		}
+		return nil
+	})
+	if err != nil {
+		respondActionError(c, err, "Failed to book charter job")
+		return
+	}
+
+	var job models.CharterJob
+	if err := h.db.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Charter job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *GameHandler) DispatchCharter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req DispatchCharterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var job models.CharterJob
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "booked").First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booked charter job not found"})
+		return
+	}
+	if simulation.Now().After(job.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The departure time has passed"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", req.BusID, company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+	if bus.Status != "available" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is not available"})
+		return
+	}
+	if bus.Capacity < job.Passengers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The job needs %d seats", job.Passengers)})
+		return
+	}
+	if job.ServiceType != "" && bus.ServiceType != job.ServiceType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The job needs a " + job.ServiceType + " bus"})
+		return
+	}
+	if bus.CurrentFuel < tripEnergy(bus, job.Distance, job.Passengers) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient fuel"})
+		return
+	}
+
+	// The bus is away from now until the job ends
+	end := job.StartsAt.Add(time.Duration(job.Duration) * time.Minute)
+
+	tx := h.db.Begin()
+
+	// The job and the bus may have moved on since they were read: a job
+	// cancelled or failed meanwhile is gone, and a bus sent on a trip is taken
+	result := tx.Model(&models.CharterJob{}).Where("id = ? AND status = ?", job.ID, "booked").Updates(map[string]interface{}{
+		"status":  "in_progress",
+		"bus_id":  bus.ID,
+		"ends_at": end,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dispatch charter"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booked charter job not found"})
+		return
+	}
+	result = tx.Model(&models.Bus{}).Where("id = ? AND status = ?", bus.ID, "available").Update("status", "on_charter")
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bus"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is not available"})
+		return
+	}
+
+	tx.Commit()
+
+	job.Status = "in_progress"
+	job.BusID = &bus.ID
+	job.EndsAt = &end
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *GameHandler) CancelCharter(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var job models.CharterJob
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "booked").First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booked charter job not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return failCharter(tx, &job, &company, "cancelled")
+	})
+	if errors.Is(err, errCharterNotBooked) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booked charter job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel charter job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// failCharter closes a booked job that will not run, charges the client's
+// cancellation fee and counts it as a cancellation against the company's
+// reputation. Charters run off the route network, so they are scored under
+// route 0.
+func failCharter(tx *gorm.DB, job *models.CharterJob, company *models.Company, status string) error {
+	// A job cancelled and failed at the same time is charged once
+	result := tx.Model(&models.CharterJob{}).Where("id = ? AND status = ?", job.ID, "booked").Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errCharterNotBooked
+	}
+	job.Status = status
+	description := fmt.Sprintf("Charter %s: %s to %s for %s", status, job.Origin, job.Destination, job.Client)
+	if err := recordTransaction(tx, company, "penalty", description, -job.CancellationFee); err != nil {
+		return err
+	}
+	return recordTripReputation(tx, company.ID, 0, simulation.TripOutcome{Cancelled: true})
+}
+
+// processCharters brings buses back from finished charters, fails booked jobs
+// whose departure passed without a bus, and at game midnight refreshes the
+// job board.
+func (w *World) processCharters(now time.Time) {
+	var finished []models.CharterJob
+	w.db.Where("status = ? AND ends_at <= ?", "in_progress", now).Find(&finished)
+	for i := range finished {
+		if err := w.completeCharter(&finished[i]); err != nil {
+			log.Printf("Failed to complete charter %d: %v", finished[i].ID, err)
+		}
+	}
+
+	var missed []models.CharterJob
+	w.db.Where("status = ? AND starts_at <= ?", "booked", now).Find(&missed)
+	for i := range missed {
+		job := &missed[i]
+		var company models.Company
+		if err := w.db.First(&company, job.CompanyID).Error; err != nil {
+			continue
+		}
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			return failCharter(tx, job, &company, "failed")
+		})
+		if errors.Is(err, errCharterNotBooked) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to fail charter %d: %v", job.ID, err)
+			continue
+		}
+		w.hub.broadcast <- WSMessage{Type: "charter_failed", Data: job, UserID: company.UserID}
+	}
+
+	if now.Hour() == 0 {
+		if err := w.db.Model(&models.CharterJob{}).
+			Where("status = ? AND offer_expires_at <= ?", "open", now).
+			Update("status", "expired").Error; err != nil {
+			log.Printf("Failed to expire charter jobs: %v", err)
+		}
+		w.postCharters(now)
+	}
+}
+
+// completeCharter pays for a finished job and returns its bus to the depot.
+func (w *World) completeCharter(job *models.CharterJob) error {
+	var company models.Company
+	if err := w.db.First(&company, job.CompanyID).Error; err != nil {
+		return err
+	}
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		job.Status = "completed"
+		if err := tx.Model(job).Update("status", job.Status).Error; err != nil {
+			return err
+		}
+		description := fmt.Sprintf("Charter: %s to %s for %s", job.Origin, job.Destination, job.Client)
+		return recordTransaction(tx, &company, "charter", description, job.Pay)
+	})
+	if err != nil {
+		return err
+	}
+
+	var bus models.Bus
+	if err := w.db.Preload("Depot").First(&bus, job.BusID).Error; err == nil {
+		energy := tripEnergy(bus, job.Distance, job.Passengers)
+		returnToDepot(w.db, &bus, energy, job.Distance, job.Distance/100*charterDamagePer100)
+	}
+
+	w.hub.broadcast <- WSMessage{Type: "charter_completed", Data: job, UserID: company.UserID}
+	return nil
+}
+
+// postCharters fills the job board with charter jobs between cities on the
+// same island.
+func (w *World) postCharters(now time.Time) {
+	var open int64
+	w.db.Model(&models.CharterJob{}).Where("status = ?", "open").Count(&open)
+
+	var cities []models.City
+	if err := w.db.Find(&cities).Error; err != nil || len(cities) < 2 {
+		return
+	}
+
+	for slot := int(open); slot < maxOpenCharters; slot++ {
+		draw := simulation.DrawCharter(now, slot)
+
+		// Client city and destination within the kind's range
+		type pair struct {
+			from, to models.City
+			km       float64
+		}
+		var pairs []pair
+		for _, from := range cities {
+			for _, to := range cities {
+				if from.ID == to.ID || from.Island != to.Island {
+					continue
+				}
+				km := math.Round(geo.Haversine(
+					geo.Point{Lat: from.Latitude, Lng: from.Longitude},
+					geo.Point{Lat: to.Latitude, Lng: to.Longitude},
+				) * roadDistanceFactor)
+				if km >= draw.Kind.MinKm && km <= draw.Kind.MaxKm {
+					pairs = append(pairs, pair{from, to, km})
+				}
+			}
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		p := pairs[int(draw.Pick*float64(len(pairs)))]
+
+		distance := 2 * p.km
+		driving := int(math.Round(distance / averageSpeedKmh * 60))
+		pay := draw.Kind.Pay(distance, draw.DwellHours)
+		job := models.CharterJob{
+			Status:          "open",
+			Kind:            draw.Kind.Kind,
+			Client:          draw.Client,
+			Origin:          p.from.Name,
+			Destination:     p.to.Name,
+			Distance:        distance,
+			Duration:        driving + draw.DwellHours*60,
+			Passengers:      draw.Passengers,
+			ServiceType:     draw.Kind.ServiceType,
+			Pay:             pay,
+			CancellationFee: simulation.CharterCancellationFee(pay),
+			StartsAt:        draw.StartsAt,
+			OfferExpiresAt:  draw.StartsAt.Add(-simulation.GameDay),
+		}
+		if err := w.db.Create(&job).Error; err != nil {
+			log.Printf("Failed to post charter job: %v", err)
+			return
+		}
+		w.hub.broadcast <- WSMessage{Type: "charter_posted", Data: job}
+	}
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxOpenContractOffers  = 3
+	contractOfferDays      = 7   // game days an offer stays open
+	maxActiveContracts     = 3   // per company
+	contractCancelFeeRatio = 0.5 // share of the penalty charged per remaining departure on cancellation
+)
+
+func (h *GameHandler) GetContracts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var offers []models.SubsidyContract
+	if err := h.db.Where("status = ? AND offer_expires_at > ?", "open", simulation.Now()).
+		Preload("Route").Order("offer_expires_at").Find(&offers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contract offers"})
+		return
+	}
+
+	var contracts []models.SubsidyContract
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Route").Order("created_at DESC").Find(&contracts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contracts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"offers":    offers,
+		"contracts": contracts,
+	})
+}
+
+func (h *GameHandler) AcceptContract(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	now := simulation.Now()
+	var contract models.SubsidyContract
+	if err := h.db.Where("id = ? AND status = ? AND offer_expires_at > ?", c.Param("id"), "open", now).
+		Preload("Route").First(&contract).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contract offer not found"})
+		return
+	}
+
+	if contract.Route.Type == "interprovince" && !gameProgression().Unlocks(company.Level).InterprovinceRoutes {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Interprovince routes are not unlocked at your level"})
+		return
+	}
+
+	var active int64
+	h.db.Model(&models.SubsidyContract{}).Where("company_id = ? AND status = ?", company.ID, "active").Count(&active)
+	if active >= maxActiveContracts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("You can run at most %d subsidy contracts at a time", maxActiveContracts)})
+		return
+	}
+
+	// Service starts at the next game midnight
+	start := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	end := start.Add(time.Duration(contract.Days) * simulation.GameDay)
+
+	tx := h.db.Begin()
+
+	result := tx.Model(&models.SubsidyContract{}).Where("id = ? AND status = ?", contract.ID, "open").Updates(map[string]interface{}{
+		"company_id": company.ID,
+		"status":     "active",
+		"starts_at":  start,
+		"ends_at":    end,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept contract"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Contract was already taken"})
+		return
+	}
+
+	// The contract comes with a permit for the route covering its term
+	permit, ok := activePermit(tx, company.ID, contract.RouteID)
+	if !ok {
+		var err error
+		if permit, err = grantPermit(tx, company.ID, contract.Route, 0); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant route permit"})
+			return
+		}
+	}
+	if permit.ExpiresAt.Before(end) {
+		if err := tx.Model(&permit).Update("expires_at", end).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extend route permit"})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	contract.CompanyID = &company.ID
+	contract.Status = "active"
+	contract.StartsAt = &start
+	contract.EndsAt = &end
+	c.JSON(http.StatusOK, contract)
+}
+
+func (h *GameHandler) CancelContract(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var contract models.SubsidyContract
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").
+		Preload("Route").First(&contract).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active contract not found"})
+		return
+	}
+
+	// Walking away costs part of the penalty for every departure still owed
+	remaining := contract.DailyDepartures * (contract.Days - contract.DaysServed)
+	fee := math.Round(float64(remaining) * contract.PenaltyPerMissed * contractCancelFeeRatio)
+
+	tx := h.db.Begin()
+
+	// Only an active contract can be cancelled; the daily tick may have
+	// completed or expired it since it was read
+	result := tx.Model(&models.SubsidyContract{}).Where("id = ? AND status = ?", contract.ID, "active").
+		Updates(map[string]interface{}{
+			"status":    "cancelled",
+			"penalties": gorm.Expr("penalties + ?", fee),
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel contract"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active contract not found"})
+		return
+	}
+	contract.Status = "cancelled"
+	contract.Penalties += fee
+
+	if fee > 0 {
+		if err := recordTransaction(tx, &company, "penalty", "Subsidy contract cancelled: "+contract.Route.Name, -fee); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
+			return
+		}
+	}
+
+	// Passengers on the route lose the service they were promised
+	if err := recordTripReputation(tx, company.ID, contract.RouteID, simulation.TripOutcome{Cancelled: true}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reputation"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"contract": contract,
+		"fee":      fee,
+	})
+}
+
+// recordContractDeparture counts a trip departure towards the company's
+// running subsidy contract on the route, if it has one.
+func recordContractDeparture(db *gorm.DB, companyID, routeID uint) error {
+	return db.Model(&models.SubsidyContract{}).
+		Where("company_id = ? AND route_id = ? AND status = ? AND starts_at <= ?", companyID, routeID, "active", simulation.Now()).
+		Update("day_departures", gorm.Expr("day_departures + 1")).Error
+}
+
+// processContracts settles the day that just ended on every running subsidy
+// contract, expires stale offers and offers new pioneer routes. It runs at
+// game midnight.
+func (w *World) processContracts(now time.Time) {
+	var contracts []models.SubsidyContract
+	if err := w.db.Where("status = ? AND starts_at < ?", "active", now).Preload("Route").Find(&contracts).Error; err != nil {
+		log.Printf("Failed to fetch subsidy contracts: %v", err)
+		return
+	}
+	for i := range contracts {
+		if err := w.settleContractDay(&contracts[i]); err != nil {
+			log.Printf("Failed to settle subsidy contract %d: %v", contracts[i].ID, err)
+		}
+	}
+
+	if err := w.db.Model(&models.SubsidyContract{}).
+		Where("status = ? AND offer_expires_at <= ?", "open", now).
+		Update("status", "expired").Error; err != nil {
+		log.Printf("Failed to expire contract offers: %v", err)
+	}
+
+	w.offerContract(now)
+}
+
+// settleContractDay pays a contract's departures for the day, fines the
+// missed ones and closes the contract when it ends or misses too many. The
+// contract is re-read under a row lock, and only the departures settled are
+// taken off the day's count, so trips dispatched meanwhile are not lost.
+func (w *World) settleContractDay(contract *models.SubsidyContract) error {
+	var company models.Company
+	if err := w.db.First(&company, contract.CompanyID).Error; err != nil {
+		return err
+	}
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		route := contract.Route
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(contract, contract.ID).Error; err != nil {
+			return err
+		}
+		contract.Route = route
+
+		settled := contract.DayDepartures
+		counted := min(settled, contract.DailyDepartures)
+		missed := contract.DailyDepartures - counted
+		payment := float64(counted) * contract.PaymentPerDeparture
+		penalty := float64(missed) * contract.PenaltyPerMissed
+
+		contract.DaysServed++
+		contract.Departures += counted
+		contract.Missed += missed
+		contract.Paid += payment
+		contract.Penalties += penalty
+		contract.DayDepartures -= settled
+
+		terms := simulation.ContractTerms{DailyDepartures: contract.DailyDepartures, Days: contract.Days}
+		switch {
+		case contract.Missed > terms.MaxMissed():
+			contract.Status = "terminated"
+		case contract.DaysServed >= contract.Days:
+			contract.Status = "completed"
+		}
+
+		if err := tx.Model(contract).Updates(map[string]interface{}{
+			"status":         contract.Status,
+			"days_served":    contract.DaysServed,
+			"departures":     contract.Departures,
+			"missed":         contract.Missed,
+			"paid":           contract.Paid,
+			"penalties":      contract.Penalties,
+			"day_departures": gorm.Expr("day_departures - ?", settled),
+		}).Error; err != nil {
+			return err
+		}
+		if payment > 0 {
+			description := fmt.Sprintf("Pioneer route subsidy: %s (%d departures)", contract.Route.Name, counted)
+			if err := recordTransaction(tx, &company, "subsidy", description, payment); err != nil {
+				return err
+			}
+		}
+		if penalty > 0 {
+			description := fmt.Sprintf("Pioneer route penalty: %s (%d missed departures)", contract.Route.Name, missed)
+			if err := recordTransaction(tx, &company, "penalty", description, -penalty); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if contract.Status != "active" {
+		w.hub.broadcast <- WSMessage{
+			Type:   "contract_" + contract.Status,
+			Data:   contract,
+			UserID: company.UserID,
+		}
+	}
+	return nil
+}
+
+// offerContract puts a new pioneer route out to tender while fewer than the
+// maximum offers are open. Routes already under contract are skipped.
+func (w *World) offerContract(now time.Time) {
+	var open int64
+	w.db.Model(&models.SubsidyContract{}).Where("status = ?", "open").Count(&open)
+	if open >= maxOpenContractOffers {
+		return
+	}
+
+	var candidates []models.Route
+	err := w.db.Where("status = ? AND popularity <= ?", "approved", simulation.PioneerMaxPopularity).
+		Where("id NOT IN (?)", w.db.Model(&models.SubsidyContract{}).Select("route_id").Where("status IN ?", []string{"open", "active"})).
+		Order("id").Find(&candidates).Error
+	if err != nil || len(candidates) == 0 {
+		return
+	}
+	day := int(now.Sub(simulation.GameStart) / simulation.GameDay)
+	route := candidates[day%len(candidates)]
+
+	terms := simulation.PioneerContractTerms(route.ID, route.Distance, route.Popularity, now)
+	contract := models.SubsidyContract{
+		RouteID:             route.ID,
+		Status:              "open",
+		DailyDepartures:     terms.DailyDepartures,
+		Days:                terms.Days,
+		PaymentPerDeparture: terms.PaymentPerDeparture,
+		PenaltyPerMissed:    terms.PenaltyPerMissed,
+		OfferExpiresAt:      now.Add(contractOfferDays * simulation.GameDay),
+	}
+	if err := w.db.Create(&contract).Error; err != nil {
+		log.Printf("Failed to offer subsidy contract for route %d: %v", route.ID, err)
+		return
+	}
+
+	contract.Route = route
+	w.hub.broadcast <- WSMessage{Type: "contract_offered", Data: contract}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"bus-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	depotMaxLevel          = 5
+	depotLevelUpgradeCost  = 250000000.0 // IDR per current level
+	depotCapacityPerLevel  = 5
+	depotWorkshopCost      = 400000000.0
+	depotFuelStationCost   = 300000000.0
+	depotChargerCost       = 750000000.0 // per charging station
+	depotChargersPerLevel  = 2
+	depotPowerPerLevel     = 200.0 // kW of grid connection added per level
+	depotResaleRatio       = 0.5   // share of the investment recovered when a depot is sold
+	workshopConditionBoost = 5.0   // condition restored per trip at depots with a workshop
+)
+
+type UpgradeDepotRequest struct {
+	Type string `json:"type" binding:"required,oneof=level workshop fuel_station charging_station"`
+}
+
+type TransferBusRequest struct {
+	DepotID uint `json:"depot_id" binding:"required"`
+}
+
+func (h *GameHandler) UpgradeDepot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req UpgradeDepotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var depot models.Depot
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&depot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Depot not found"})
+		return
+	}
+
+	if err := h.upgradeDepot(&company, &depot, req.Type); err != nil {
+		respondActionError(c, err, "Failed to upgrade depot")
+		return
+	}
+
+	c.JSON(http.StatusOK, depot)
+}
+
+// upgradeDepot builds a depot upgrade of the given type for the company.
+func (h *GameHandler) upgradeDepot(company *models.Company, depot *models.Depot, upgrade string) error {
+	var cost float64
+	var description string
+	switch upgrade {
+	case "level":
+		if depot.Level >= depotMaxLevel {
+			return refuse(http.StatusBadRequest, "Depot is already at maximum level")
+		}
+		cost = depotLevelUpgradeCost * float64(depot.Level)
+		depot.Level++
+		depot.Capacity += depotCapacityPerLevel
+		depot.PowerCapacity += depotPowerPerLevel
+		description = fmt.Sprintf("Depot upgrade to level %d: %s", depot.Level, depot.Name)
+	case "workshop":
+		if depot.HasWorkshop {
+			return refuse(http.StatusBadRequest, "Depot already has a workshop")
+		}
+		cost = depotWorkshopCost
+		depot.HasWorkshop = true
+		description = "Depot workshop: " + depot.Name
+	case "fuel_station":
+		if depot.HasFuelStation {
+			return refuse(http.StatusBadRequest, "Depot already has a fuel station")
+		}
+		cost = depotFuelStationCost
+		depot.HasFuelStation = true
+		description = "Depot fuel station: " + depot.Name
+	case "charging_station":
+		if depot.Chargers >= depotChargersPerLevel*depot.Level {
+			return refuse(http.StatusBadRequest, fmt.Sprintf("A level %d depot has room for %d charging stations", depot.Level, depotChargersPerLevel*depot.Level))
+		}
+		cost = depotChargerCost
+		depot.Chargers++
+		description = fmt.Sprintf("Depot charging station %d: %s", depot.Chargers, depot.Name)
+	}
+
+	depot.Investment += cost
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(depot).Updates(map[string]interface{}{
+			"level":            depot.Level,
+			"capacity":         depot.Capacity,
+			"has_workshop":     depot.HasWorkshop,
+			"has_fuel_station": depot.HasFuelStation,
+			"chargers":         depot.Chargers,
+			"power_capacity":   depot.PowerCapacity,
+			"investment":       depot.Investment,
+		}).Error; err != nil {
+			return err
+		}
+		err := spendFunds(tx, company, "expense", description, cost)
+		return refusePayment(err, "Insufficient funds")
+	})
+}
+
+func (h *GameHandler) SellDepot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var depot models.Depot
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&depot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Depot not found"})
+		return
+	}
+
+	var busCount int64
+	h.db.Model(&models.Bus{}).Where("depot_id = ?", depot.ID).Count(&busCount)
+	if busCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transfer all buses out of the depot before closing it"})
+		return
+	}
+
+	saleValue := depot.Investment * depotResaleRatio
+
+	tx := h.db.Begin()
+
+	if err := tx.Delete(&depot).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close depot"})
+		return
+	}
+
+	if saleValue > 0 {
+		if err := recordTransaction(tx, &company, "sale", "Sold depot: "+depot.Name, saleValue); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Depot closed",
+		"sale_value": saleValue,
+	})
+}
+
+func (h *GameHandler) TransferBus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req TransferBusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	if bus.Status != "available" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only available buses can be transferred"})
+		return
+	}
+
+	if bus.DepotID == req.DepotID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is already at this depot"})
+		return
+	}
+
+	var target models.Depot
+	if err := h.db.Where("id = ? AND company_id = ?", req.DepotID, company.ID).First(&target).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Depot not found or not owned by company"})
+		return
+	}
+
+	if target.CurrentBuses >= target.Capacity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Depot is at full capacity"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Depot{}).Where("id = ?", bus.DepotID).
+			Update("current_buses", gorm.Expr("current_buses - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&target).Update("current_buses", gorm.Expr("current_buses + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&bus).Update("depot_id", target.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer bus"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bus)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEmissionsReportDays = 30
+	maxEmissionsReportDays     = 365
+	emissionsMonth             = 30 * simulation.GameDay
+)
+
+var (
+	carbonTaxOnce sync.Once
+	carbonTax     float64
+)
+
+// carbonTaxRate is the carbon tax in IDR per tonne of CO2, read from
+// CARBON_TAX_PER_TONNE when set. Zero disables the tax.
+func carbonTaxRate() float64 {
+	carbonTaxOnce.Do(func() {
+		carbonTax = simulation.DefaultCarbonTaxPerTonne
+		if value := os.Getenv("CARBON_TAX_PER_TONNE"); value != "" {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 {
+				log.Printf("Invalid CARBON_TAX_PER_TONNE %q, using %.0f", value, carbonTax)
+				return
+			}
+			carbonTax = rate
+		}
+	})
+	return carbonTax
+}
+
+// EmissionsTotals sums the emissions of a set of completed trips.
+type EmissionsTotals struct {
+	Trips       int     `json:"trips"`
+	CO2         float64 `json:"co2"` // kg
+	PassengerKm float64 `json:"passenger_km"`
+	Intensity   float64 `json:"intensity"` // g CO2 per passenger-km
+}
+
+func (t *EmissionsTotals) add(co2, passengerKm float64) {
+	t.Trips++
+	t.CO2 += co2
+	t.PassengerKm += passengerKm
+	t.Intensity = simulation.EmissionIntensity(t.CO2, t.PassengerKm)
+}
+
+// EmissionsBreakdown is the emissions of one bus, route or powertrain.
+type EmissionsBreakdown struct {
+	ID   uint   `json:"id,omitempty"`
+	Name string `json:"name"`
+	EmissionsTotals
+}
+
+func (h *GameHandler) GetEmissions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultEmissionsReportDays)))
+	if err != nil || days < 1 || days > maxEmissionsReportDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxEmissionsReportDays)})
+		return
+	}
+
+	var rows []struct {
+		BusID       uint
+		BusName     string
+		Powertrain  string
+		RouteID     uint
+		RouteName   string
+		CO2         float64
+		PassengerKm float64
+	}
+	since := time.Now().Add(-simulation.RealDuration(time.Duration(days) * simulation.GameDay))
+	if err := h.db.Table("trips").
+		Select("trips.bus_id, COALESCE(buses.name, '') AS bus_name, trips.powertrain, trips.route_id, routes.name AS route_name, trips.co2, trips.passenger_km").
+		Joins("LEFT JOIN buses ON buses.id = trips.bus_id").
+		Joins("JOIN routes ON routes.id = trips.route_id").
+		Where("trips.company_id = ? AND trips.status = ? AND trips.actual_end >= ?", company.ID, "completed", since).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trips"})
+		return
+	}
+
+	var total EmissionsTotals
+	byPowertrain := map[string]*EmissionsBreakdown{}
+	byBus := map[uint]*EmissionsBreakdown{}
+	byRoute := map[uint]*EmissionsBreakdown{}
+	for _, row := range rows {
+		total.add(row.CO2, row.PassengerKm)
+		if byPowertrain[row.Powertrain] == nil {
+			byPowertrain[row.Powertrain] = &EmissionsBreakdown{Name: row.Powertrain}
+		}
+		byPowertrain[row.Powertrain].add(row.CO2, row.PassengerKm)
+		if byBus[row.BusID] == nil {
+			byBus[row.BusID] = &EmissionsBreakdown{ID: row.BusID, Name: row.BusName}
+		}
+		byBus[row.BusID].add(row.CO2, row.PassengerKm)
+		if byRoute[row.RouteID] == nil {
+			byRoute[row.RouteID] = &EmissionsBreakdown{ID: row.RouteID, Name: row.RouteName}
+		}
+		byRoute[row.RouteID].add(row.CO2, row.PassengerKm)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":          days,
+		"total":         total,
+		"green_rating":  simulation.FindGreenRating(company.GreenRating),
+		"ratings":       simulation.GreenRatings,
+		"carbon_tax":    carbonTaxRate(),
+		"estimated_tax": math.Round(total.CO2 / 1000 * carbonTaxRate()),
+		"powertrains":   breakdownList(byPowertrain),
+		"buses":         breakdownList(byBus),
+		"routes":        breakdownList(byRoute),
+	})
+}
+
+func breakdownList[K comparable](m map[K]*EmissionsBreakdown) []EmissionsBreakdown {
+	list := make([]EmissionsBreakdown, 0, len(m))
+	for _, b := range m {
+		list = append(list, *b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CO2 > list[j].CO2 })
+	return list
+}
+
+// refreshGreenRatings grades every company on its CO2 per passenger-km over
+// the last game month. Companies without trips get the neutral grade. It runs
+// at game midnight.
+func (w *World) refreshGreenRatings() {
+	since := time.Now().Add(-simulation.RealDuration(emissionsMonth))
+	co2, err := tripTotals(w.db, "SUM(trips.co2)", "trips.actual_end >= ?", since)
+	if err != nil {
+		log.Printf("Failed to total emissions: %v", err)
+		return
+	}
+	passengerKm, err := tripTotals(w.db, "SUM(trips.passenger_km)", "trips.actual_end >= ?", since)
+	if err != nil {
+		log.Printf("Failed to total passenger-km: %v", err)
+		return
+	}
+
+	var companies []models.Company
+	w.db.Find(&companies)
+	for _, company := range companies {
+		grade := simulation.NeutralGreenRating
+		if passengerKm[company.ID] > 0 {
+			grade = simulation.RateEmissions(simulation.EmissionIntensity(co2[company.ID], passengerKm[company.ID])).Grade
+		}
+		if grade != company.GreenRating {
+			w.db.Model(&company).Update("green_rating", grade)
+		}
+	}
+}
+
+// settleEmissions levies the carbon tax on every company's emissions over the
+// last game month and pays green-rated companies the government subsidy for
+// the passengers they carried on zero-emission buses. It runs on the first of
+// every game month.
+func (w *World) settleEmissions() {
+	since := time.Now().Add(-simulation.RealDuration(emissionsMonth))
+	co2, err := tripTotals(w.db, "SUM(trips.co2)", "trips.actual_end >= ?", since)
+	if err != nil {
+		log.Printf("Failed to total emissions: %v", err)
+		return
+	}
+	electricKm, err := tripTotals(w.db, "SUM(trips.passenger_km)", "trips.powertrain = ? AND trips.actual_end >= ?", simulation.PowertrainElectric, since)
+	if err != nil {
+		log.Printf("Failed to total zero-emission passenger-km: %v", err)
+		return
+	}
+
+	var companies []models.Company
+	w.db.Find(&companies)
+	for i := range companies {
+		company := &companies[i]
+		tax := math.Round(co2[company.ID] / 1000 * carbonTaxRate())
+		subsidy := math.Round(electricKm[company.ID] * simulation.FindGreenRating(company.GreenRating).Subsidy)
+		if tax == 0 && subsidy == 0 {
+			continue
+		}
+
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			if tax > 0 {
+				description := fmt.Sprintf("Carbon tax: %.1f t CO2", co2[company.ID]/1000)
+				if err := recordTransaction(tx, company, "carbon_tax", description, -tax); err != nil {
+					return err
+				}
+			}
+			if subsidy > 0 {
+				description := fmt.Sprintf("Green operations subsidy (rating %s): %.0f zero-emission passenger-km", company.GreenRating, electricKm[company.ID])
+				if err := recordTransaction(tx, company, "subsidy", description, subsidy); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to settle emissions for company %d: %v", company.ID, err)
+			continue
+		}
+
+		w.hub.broadcast <- WSMessage{
+			Type: "emissions_settled",
+			Data: gin.H{
+				"co2":          co2[company.ID],
+				"carbon_tax":   tax,
+				"green_rating": company.GreenRating,
+				"subsidy":      subsidy,
+			},
+			UserID: company.UserID,
+		}
+	}
+}
@@ -1,24 +1,34 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strings"
 
+	"bus-manager/internal/geo"
 	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
 
+// startingCapital covers a depot, a route permit and a first catalog bus.
+const startingCapital = 2500000000.0 // IDR
+
 type GameHandler struct {
 	db  *gorm.DB
 	rdb *redis.Client
+	hub *WSHub
 }
 
-func NewGameHandler(db *gorm.DB, rdb *redis.Client) *GameHandler {
+func NewGameHandler(db *gorm.DB, rdb *redis.Client, hub *WSHub) *GameHandler {
 	return &GameHandler{
 		db:  db,
 		rdb: rdb,
+		hub: hub,
 	}
 }
 
@@ -34,16 +44,19 @@ type CreateDepotRequest struct {
 
 type CreateBusRequest struct {
 	Name          string  `json:"name" binding:"required,min=3,max=100"`
-	Type          string  `json:"type" binding:"required"`
-	Capacity      int     `json:"capacity" binding:"required,min=1"`
+	Model         string  `json:"model"`                                 // catalog model; sets type, capacity, powertrain and price
+	Type          string  `json:"type" binding:"required_without=Model"` // without a model, buys the diesel catalog bus of this type
+	Capacity      int     `json:"capacity" binding:"omitempty,min=1"`    // with the capacity closest to this one
 	ServiceType   string  `json:"service_type" binding:"required"`
-	PurchasePrice float64 `json:"purchase_price" binding:"required,min=0"`
+	PurchasePrice float64 `json:"purchase_price"` // ignored; the catalog sets the price
+	DepotID       uint    `json:"depot_id"`       // defaults to the first depot with free capacity
 }
 
 type CreateTripRequest struct {
-	BusID    uint `json:"bus_id" binding:"required"`
-	RouteID  uint `json:"route_id" binding:"required"`
-	DriverID uint `json:"driver_id"`
+	BusID      uint `json:"bus_id" binding:"required"`
+	RouteID    uint `json:"route_id" binding:"required"`
+	DriverID   uint `json:"driver_id"`
+	AvoidTolls bool `json:"avoid_tolls"` // take the non-toll alternatives where there are any
 }
 
 func (h *GameHandler) GetCompany(c *gin.Context) {
@@ -73,31 +86,45 @@ func (h *GameHandler) CreateCompany(c *gin.Context) {
 		return
 	}
 
-	// Check if company already exists
-	var existingCompany models.Company
-	if err := h.db.Where("user_id = ?", userID).First(&existingCompany).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Company already exists"})
-		return
-	}
-
 	var req CreateCompanyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	var company models.Company
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		company, err = createCompany(tx, userID.(uint), req.Name)
+		return err
+	})
+	if err != nil {
+		respondActionError(c, err, "Failed to create company")
+		return
+	}
+
+	c.JSON(http.StatusCreated, company)
+}
+
+// createCompany founds a user's company with the starting capital. Run it
+// inside the caller's database transaction.
+func createCompany(tx *gorm.DB, userID uint, name string) (models.Company, error) {
+	// Check if company already exists
+	var existingCompany models.Company
+	if err := tx.Where("user_id = ?", userID).First(&existingCompany).Error; err == nil {
+		return models.Company{}, refuse(http.StatusConflict, "Company already exists")
+	}
+
 	company := models.Company{
-		UserID:     userID.(uint),
-		Name:       req.Name,
-		Money:      1000000, // Starting money 1 million IDR
-		Reputation: 0,
+		UserID:     userID,
+		Name:       name,
+		Money:      startingCapital,
+		Reputation: int(simulation.ReputationBaseline),
 		Level:      1,
 		Experience: 0,
 	}
-
-	if err := h.db.Create(&company).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create company"})
-		return
+	if err := tx.Create(&company).Error; err != nil {
+		return models.Company{}, err
 	}
 
 	// Create initial transaction
@@ -105,12 +132,10 @@ func (h *GameHandler) CreateCompany(c *gin.Context) {
 		CompanyID:   company.ID,
 		Type:        "income",
 		Description: "Starting capital",
-		Amount:      1000000,
-		Balance:     1000000,
+		Amount:      startingCapital,
+		Balance:     startingCapital,
 	}
-	h.db.Create(&transaction)
-
-	c.JSON(http.StatusCreated, company)
+	return company, tx.Create(&transaction).Error
 }
 
 func (h *GameHandler) GetDepots(c *gin.Context) {
@@ -156,6 +181,30 @@ func (h *GameHandler) CreateDepot(c *gin.Context) {
 		return
 	}
 
+	depot, err := h.buildDepot(&company, req)
+	if err != nil {
+		respondActionError(c, err, "Failed to create depot")
+		return
+	}
+
+	c.JSON(http.StatusCreated, depot)
+}
+
+// buildDepot buys the land for a new depot of the company.
+func (h *GameHandler) buildDepot(company *models.Company, req CreateDepotRequest) (models.Depot, error) {
+	// Check the company may run another depot at its level
+	var depotCount int64
+	h.db.Model(&models.Depot{}).Where("company_id = ?", company.ID).Count(&depotCount)
+	if depotCount >= int64(maxDepotsForLevel(company.Level)) {
+		return models.Depot{}, refuse(http.StatusBadRequest, fmt.Sprintf("Level %d companies can operate at most %d depots", company.Level, maxDepotsForLevel(company.Level)))
+	}
+
+	// Validate the location and price the land
+	quote := quoteLand(h.db, geo.Point{Lat: req.Latitude, Lng: req.Longitude})
+	if !quote.Allowed {
+		return models.Depot{}, refuse(http.StatusBadRequest, quote.Reason)
+	}
+
 	depot := models.Depot{
 		CompanyID:    company.ID,
 		Name:         req.Name,
@@ -164,14 +213,17 @@ func (h *GameHandler) CreateDepot(c *gin.Context) {
 		Capacity:     10,
 		CurrentBuses: 0,
 		Level:        1,
+		Investment:   quote.Price,
 	}
 
-	if err := h.db.Create(&depot).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create depot"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, depot)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&depot).Error; err != nil {
+			return err
+		}
+		err := spendFunds(tx, company, "purchase", "Depot land near "+quote.NearestCity+": "+depot.Name, quote.Price)
+		return refusePayment(err, "Insufficient funds")
+	})
+	return depot, err
 }
 
 func (h *GameHandler) GetBuses(c *gin.Context) {
@@ -217,86 +269,120 @@ func (h *GameHandler) CreateBus(c *gin.Context) {
 		return
 	}
 
-	// Check if company has enough money
-	if company.Money < req.PurchasePrice {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient funds"})
+	bus, err := h.buyBus(&company, req)
+	if err != nil {
+		respondActionError(c, err, "Failed to create bus")
 		return
 	}
 
-	// Get first depot (for now)
-	var depot models.Depot
-	if err := h.db.Where("company_id = ?", company.ID).First(&depot).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No depot found. Create a depot first."})
-		return
-	}
+	c.JSON(http.StatusCreated, bus)
+}
 
-	// Check depot capacity
-	if depot.CurrentBuses >= depot.Capacity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Depot is at full capacity"})
-		return
+// buyBus buys a catalog bus for the company into the requested depot.
+func (h *GameHandler) buyBus(company *models.Company, req CreateBusRequest) (models.Bus, error) {
+	// Older clients ask for a type and capacity instead of a model
+	if req.Model == "" {
+		model, ok := simulation.DieselBusModelFor(req.Type, req.Capacity)
+		if !ok {
+			return models.Bus{}, refuse(http.StatusBadRequest, "No catalog bus of type "+req.Type)
+		}
+		req.Model = model.Model
 	}
 
-	bus := models.Bus{
-		CompanyID:     company.ID,
-		DepotID:       depot.ID,
-		Name:          req.Name,
-		Type:          req.Type,
-		Capacity:      req.Capacity,
-		FuelCapacity:  100,
-		CurrentFuel:   100,
-		Range:         500,
-		ServiceType:   req.ServiceType,
-		Status:        "available",
-		Condition:     100,
-		PurchasePrice: req.PurchasePrice,
-		OperatingCost: 1000, // Default operating cost per km
-	}
-
-	// Start transaction
-	tx := h.db.Begin()
-
-	// Create bus
-	if err := tx.Create(&bus).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bus"})
-		return
+	// Buses are bought from the catalog at its list price
+	var bus models.Bus
+	if model, ok := simulation.FindDieselBusModel(req.Model); ok {
+		bus = models.Bus{
+			Type:          model.Type,
+			Capacity:      model.Capacity,
+			Powertrain:    simulation.PowertrainDiesel,
+			Model:         model.Model,
+			FuelCapacity:  simulation.DieselTankLiters,
+			CurrentFuel:   simulation.DieselTankLiters,
+			Range:         simulation.DieselRangeKm,
+			PurchasePrice: model.Price,
+		}
+	} else if model, ok := simulation.FindElectricBusModel(req.Model); ok {
+		bus = models.Bus{
+			Type:          model.Type,
+			Capacity:      model.Capacity,
+			Powertrain:    simulation.PowertrainElectric,
+			Model:         model.Model,
+			Consumption:   model.Consumption,
+			FuelCapacity:  model.BatteryKWh,
+			CurrentFuel:   model.BatteryKWh,
+			Range:         model.Range(),
+			PurchasePrice: model.Price,
+		}
+	} else {
+		return models.Bus{}, refuse(http.StatusBadRequest, "Unknown bus model: "+req.Model)
 	}
 
-	// Update company money
-	company.Money -= req.PurchasePrice
-	if err := tx.Save(&company).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
-		return
+	// Check the bus type is unlocked at the company's level
+	requiredLevel, known := gameProgression().BusTypeLevel(bus.Type)
+	if !known {
+		return models.Bus{}, refuse(http.StatusBadRequest, "Unknown bus type: "+bus.Type)
+	}
+	if requiredLevel > company.Level {
+		return models.Bus{}, refuse(http.StatusBadRequest, fmt.Sprintf("Bus type %s unlocks at level %d", bus.Type, requiredLevel))
 	}
 
-	// Update depot current buses
-	depot.CurrentBuses++
-	if err := tx.Save(&depot).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update depot"})
-		return
+	// Get the requested depot, or the first one with room for the bus
+	var depot models.Depot
+	if req.DepotID != 0 {
+		if err := h.db.Where("id = ? AND company_id = ?", req.DepotID, company.ID).First(&depot).Error; err != nil {
+			return models.Bus{}, refuse(http.StatusBadRequest, "Depot not found or not owned by company")
+		}
+	} else if err := h.db.Where("company_id = ? AND current_buses < capacity", company.ID).Order("id").First(&depot).Error; err != nil {
+		if err := h.db.Where("company_id = ?", company.ID).First(&depot).Error; err != nil {
+			return models.Bus{}, refuse(http.StatusBadRequest, "No depot found. Create a depot first.")
+		}
 	}
 
-	// Create transaction record
-	transaction := models.Transaction{
-		CompanyID:   company.ID,
-		Type:        "expense",
-		Description: "Purchased bus: " + req.Name,
-		Amount:      -req.PurchasePrice,
-		Balance:     company.Money,
+	// Check depot capacity
+	if depot.CurrentBuses >= depot.Capacity {
+		return models.Bus{}, refuse(http.StatusBadRequest, "Depot is at full capacity")
+	}
+
+	bus.CompanyID = company.ID
+	bus.DepotID = depot.ID
+	bus.Name = req.Name
+	bus.ServiceType = req.ServiceType
+	bus.Status = "available"
+	bus.Condition = 100
+	bus.OperatingCost = 1000 // Default operating cost per km
+	bus.PurchasedAt = simulation.Now()
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Create bus
+		if err := tx.Create(&bus).Error; err != nil {
+			return err
+		}
+
+		// Update company money
+		if err := spendFunds(tx, company, "expense", "Purchased bus: "+req.Name, bus.PurchasePrice); err != nil {
+			return refusePayment(err, "Insufficient funds")
+		}
+
+		// Update depot current buses
+		depot.CurrentBuses++
+		return tx.Save(&depot).Error
+	})
+	if err != nil {
+		return models.Bus{}, err
 	}
-	tx.Create(&transaction)
 
-	// Commit transaction
-	tx.Commit()
+	h.hub.PublishEvent(company.ID, simulation.GameEvent{
+		Type:   simulation.EventBusBought,
+		Values: map[string]float64{"price": bus.PurchasePrice},
+	})
 
-	c.JSON(http.StatusCreated, bus)
+	return bus, nil
 }
 
 func (h *GameHandler) GetRoutes(c *gin.Context) {
 	var routes []models.Route
-	if err := h.db.Find(&routes).Error; err != nil {
+	if err := h.db.Where("status = ?", "approved").Preload("Stops", orderBySequence).Preload("Charges", orderBySequence).Find(&routes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch routes"})
 		return
 	}
@@ -324,60 +410,200 @@ func (h *GameHandler) CreateTrip(c *gin.Context) {
 		return
 	}
 
+	trip, err := h.dispatchTrip(&company, req)
+	if err != nil {
+		respondActionError(c, err, "Failed to create trip")
+		return
+	}
+
+	c.JSON(http.StatusCreated, trip)
+}
+
+// dispatchTrip loads a company bus with passengers and parcels for a trip on a
+// permitted route and sends it on its way.
+func (h *GameHandler) dispatchTrip(company *models.Company, req CreateTripRequest) (models.Trip, error) {
 	// Validate bus ownership
 	var bus models.Bus
 	if err := h.db.Where("id = ? AND company_id = ?", req.BusID, company.ID).First(&bus).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus not found or not owned by company"})
-		return
+		return models.Trip{}, refuse(http.StatusBadRequest, "Bus not found or not owned by company")
+	}
+
+	// A bus whose charge completed since the last tick is ready to go
+	if bus.Status == "charging" && bus.ChargingUntil != nil && !bus.ChargingUntil.After(simulation.Now()) {
+		if err := finishCharging(h.db, &bus); err != nil {
+			return models.Trip{}, err
+		}
+	}
+	if bus.Status == "charging" {
+		if bus.ChargingUntil == nil {
+			return models.Trip{}, refuse(http.StatusBadRequest, "Bus is charging")
+		}
+		return models.Trip{}, refuse(http.StatusBadRequest, "Bus is charging until "+bus.ChargingUntil.Format("2006-01-02 15:04"))
 	}
 
 	// Check if bus is available
 	if bus.Status != "available" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus is not available"})
-		return
+		return models.Trip{}, refuse(http.StatusBadRequest, "Bus is not available")
 	}
 
 	// Get route
 	var route models.Route
-	if err := h.db.First(&route, req.RouteID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Route not found"})
-		return
+	if err := h.db.Where("status = ?", "approved").Preload("Stops", orderBySequence).Preload("Charges", orderBySequence).First(&route, req.RouteID).Error; err != nil {
+		return models.Trip{}, refuse(http.StatusBadRequest, "Route not found")
 	}
 
-	// Check if bus has enough fuel
-	if bus.CurrentFuel < route.Distance/10 { // Assuming 1 liter per 10 km
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient fuel"})
-		return
+	// Landslides and severe floods close the road until it is cleared
+	if closure, closed := activeRoadClosure(h.db, route.ID, simulation.Now()); closed {
+		return models.Trip{}, refuse(http.StatusConflict, fmt.Sprintf("The road is closed by a %s until %s",
+			strings.ReplaceAll(closure.Type, "_", " "), closure.ClosedUntil.Format("2006-01-02 15:04")))
+	}
+
+	// Check the route and service are unlocked at the company's level
+	unlocks := gameProgression().Unlocks(company.Level)
+	if route.Type == "interprovince" && !unlocks.InterprovinceRoutes {
+		return models.Trip{}, refuse(http.StatusForbidden, "Interprovince routes are not unlocked at your level")
+	}
+	if bus.ServiceType == "night" && !unlocks.NightService {
+		return models.Trip{}, refuse(http.StatusForbidden, "Night service is not unlocked at your level")
+	}
+
+	// Check the company holds a permit (izin trayek) for the route
+	permit, ok := activePermit(h.db, company.ID, route.ID)
+	if !ok {
+		return models.Trip{}, refuse(http.StatusForbidden, "No active route permit for this route")
+	}
+
+	// Tolls and ferries on the way, or the detours around the tolls
+	charges, detourKm, detourMinutes := tripCharges(route, bus, req.AvoidTolls)
+	fees := chargesTotal(charges)
+
+	// Check if a diesel bus has enough fuel; load decides an electric bus's range
+	if bus.Powertrain != simulation.PowertrainElectric && bus.CurrentFuel < tripEnergy(bus, route.Distance+detourKm, 0) {
+		return models.Trip{}, refuse(http.StatusBadRequest, "Insufficient fuel")
+	}
+
+	// Calculate passengers boarding and alighting at each stop and the fares
+	// they pay, at the fare the company set on its permit
+	stops := routeStops(route)
+	segmentFares := make([]float64, 0, len(stops)-1)
+	fare := 0.0
+	for _, stop := range stops[1:] {
+		segmentFare := math.Round(stop.Fare * permit.FareMultiplier)
+		segmentFares = append(segmentFares, segmentFare)
+		fare += segmentFare
+	}
+	popularity := routeDemandPopularity(h.db, *company, route, bus.ServiceType)
+	popularity = int(math.Min(maxDemandPopularity, math.Round(float64(popularity)*simulation.FareDemand(permit.FareMultiplier))))
+	loads, passengers, revenue := simulation.LoadStops(bus.Capacity, popularity, segmentFares)
+
+	// Electric buses draw more with a full load and the AC on
+	peakOnBoard := 0
+	for _, load := range loads {
+		peakOnBoard = max(peakOnBoard, load.OnBoard)
+	}
+	energy := tripEnergy(bus, route.Distance+detourKm, peakOnBoard)
+	if bus.Powertrain == simulation.PowertrainElectric && bus.CurrentFuel < energy {
+		return models.Trip{}, refuse(http.StatusBadRequest, fmt.Sprintf("Insufficient charge: the trip needs %.0f kWh, the battery holds %.0f kWh", energy, bus.CurrentFuel))
 	}
 
-	// Calculate revenue based on passengers and fare
-	passengers := int(float64(bus.Capacity) * float64(route.Popularity) / 100.0)
-	revenue := float64(passengers) * route.BaseFare
-	cost := route.Distance * bus.OperatingCost
-	profit := revenue - cost
+	// Emissions are accounted per passenger-km for the company's green rating
+	passengerKm := 0.0
+	for i, stop := range stops[1:] {
+		passengerKm += float64(loads[i].OnBoard) * stop.Distance
+	}
+
+	// Parcels consigned at the origin fill the hold space the luggage leaves
+	distances := make([]float64, 0, len(stops)-1)
+	travelled := 0.0
+	for _, stop := range stops[1:] {
+		travelled += stop.Distance
+		distances = append(distances, travelled)
+	}
+	hold := simulation.HoldCapacity(bus.Capacity) - float64(peakOnBoard)*simulation.LuggagePerPassengerKg
+	parcelLoads, cargoKg, cargoRevenue := simulation.LoadParcels(hold, popularity, distances)
+	parcels := make([]models.TripParcel, len(parcelLoads))
+	for i, load := range parcelLoads {
+		parcels[i] = models.TripParcel{
+			City:     stops[load.Stop].City,
+			Terminal: stops[load.Stop].Terminal,
+			WeightKg: load.WeightKg,
+			Fee:      load.Fee,
+		}
+	}
+
+	cost := (route.Distance+detourKm)*bus.OperatingCost + fees
+	profit := revenue + cargoRevenue - cost
+
+	tripStops := make([]models.TripStop, len(stops))
+	for i, stop := range stops {
+		tripStops[i] = models.TripStop{
+			Sequence:  stop.Sequence,
+			City:      stop.City,
+			Terminal:  stop.Terminal,
+			Boarding:  loads[i].Boarding,
+			Alighting: loads[i].Alighting,
+			OnBoard:   loads[i].OnBoard,
+			Revenue:   loads[i].Revenue,
+			Status:    "pending",
+		}
+	}
 
 	trip := models.Trip{
-		BusID:      req.BusID,
-		RouteID:    req.RouteID,
-		DriverID:   req.DriverID,
-		Status:     "planned",
-		Passengers: passengers,
-		Revenue:    revenue,
-		Cost:       cost,
-		Profit:     profit,
-		Progress:   0,
-	}
-
-	if err := h.db.Create(&trip).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create trip"})
-		return
+		BusID:         req.BusID,
+		CompanyID:     company.ID,
+		RouteID:       req.RouteID,
+		DriverID:      req.DriverID,
+		Status:        "planned",
+		Passengers:    passengers,
+		Revenue:       revenue,
+		Fare:          fare,
+		Cost:          cost,
+		Profit:        profit,
+		Progress:      0,
+		AvoidTolls:    req.AvoidTolls,
+		DetourKm:      detourKm,
+		DetourMinutes: detourMinutes,
+		EnergyUsed:    energy,
+		CO2:           simulation.TripCO2(bus.Powertrain, energy),
+		Powertrain:    bus.Powertrain,
+		PassengerKm:   passengerKm,
+		CargoKg:       cargoKg,
+		CargoRevenue:  cargoRevenue,
+		Stops:         tripStops,
+		Charges:       charges,
+		Parcels:       parcels,
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&trip).Error; err != nil {
+			return err
+		}
+
+		// Tolls and ferry tickets are paid on departure
+		if err := payTripCharges(tx, company, route, charges); err != nil {
+			return refusePayment(err, "Insufficient funds for tolls and ferries")
+		}
+
+		// Dispatching a worn-out bus counts against the permit
+		if bus.Condition < minServiceCondition {
+			if err := tx.Model(&permit).Update("violations", gorm.Expr("violations + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		return recordContractDeparture(tx, company.ID, route.ID)
+	})
+	if err != nil {
+		return models.Trip{}, err
 	}
 
 	// Update bus status
 	bus.Status = "on_trip"
 	h.db.Save(&bus)
 
-	c.JSON(http.StatusCreated, trip)
+	h.hub.StartTripSimulation(trip.ID)
+
+	return trip, nil
 }
 
 func (h *GameHandler) GetActiveTrips(c *gin.Context) {
@@ -395,11 +621,13 @@ func (h *GameHandler) GetActiveTrips(c *gin.Context) {
 	}
 
 	var trips []models.Trip
-	if err := h.db.Where("status IN ? AND bus_id IN (SELECT id FROM buses WHERE company_id = ?)",
-		[]string{"planned", "active"}, company.ID).
+	if err := h.db.Where("status IN ? AND company_id = ?", []string{"planned", "active"}, company.ID).
 		Preload("Bus").
 		Preload("Route").
 		Preload("Driver").
+		Preload("Stops", orderBySequence).
+		Preload("Charges").
+		Preload("Parcels").
 		Find(&trips).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch active trips"})
 		return
@@ -407,3 +635,28 @@ func (h *GameHandler) GetActiveTrips(c *gin.Context) {
 
 	c.JSON(http.StatusOK, trips)
 }
+
+func orderBySequence(db *gorm.DB) *gorm.DB {
+	return db.Order("sequence")
+}
+
+// routeStops returns the ordered stops of a route. Routes without explicit stops
+// are treated as a single segment between origin and destination.
+func routeStops(route models.Route) []models.RouteStop {
+	if len(route.Stops) >= 2 {
+		return route.Stops
+	}
+	return []models.RouteStop{
+		{RouteID: route.ID, Sequence: 0, City: route.Origin, Latitude: route.OriginLat, Longitude: route.OriginLng},
+		{
+			RouteID:   route.ID,
+			Sequence:  1,
+			City:      route.Destination,
+			Latitude:  route.DestLat,
+			Longitude: route.DestLng,
+			Distance:  route.Distance,
+			Duration:  route.Duration,
+			Fare:      route.BaseFare,
+		},
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const incidentListLimit = 50
+
+func (h *GameHandler) GetIncidents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var incidents []models.Incident
+	if err := h.db.Where("company_id = ?", company.ID).Order("id DESC").Limit(incidentListLimit).Find(&incidents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	var claims []models.InsuranceClaim
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Incident").Order("id DESC").Limit(incidentListLimit).Find(&claims).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch claims"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incidents": incidents,
+		"claims":    claims,
+	})
+}
+
+// planIncidents draws the incidents that will happen on a trip, seeded by the
+// trip so a replay produces the same ones. Rain anywhere on the route raises
+// the risk of each incident type by its own rain multiplier.
+func planIncidents(trip models.Trip, weather []simulation.Weather) []simulation.GeneratedIncident {
+	return simulation.GenerateIncidents(int64(trip.ID), simulation.IncidentConditions{
+		DistanceKm: trip.Route.Distance,
+		Condition:  trip.Bus.Condition,
+		Night:      trip.Bus.ServiceType == "night",
+		Rain:       simulation.WorstWeather(weather).Raining(),
+		Passengers: trip.Passengers,
+	})
+}
+
+// recordIncident stores an incident at a point of the trip, charges its cost
+// to the operating company and claims what the bus's insurance covers.
+func recordIncident(db *gorm.DB, trip models.Trip, generated simulation.GeneratedIncident, position geo.Point) (models.Incident, error) {
+	incident := models.Incident{
+		TripID:       trip.ID,
+		CompanyID:    trip.CompanyID,
+		RouteID:      trip.RouteID,
+		BusID:        trip.BusID,
+		Type:         generated.Type,
+		Severity:     generated.Severity,
+		Progress:     generated.At * 100,
+		Latitude:     position.Lat,
+		Longitude:    position.Lng,
+		DelayMinutes: generated.DelayMinutes,
+		Cost:         generated.Cost,
+		Compensation: generated.Compensation,
+		Damage:       generated.Damage,
+		Injuries:     generated.Injuries,
+		RoadClosed:   generated.RoadClosed,
+		GameTime:     simulation.Now(),
+	}
+
+	if incident.RoadClosed {
+		until := incident.GameTime.Add(simulation.RoadClosure(incident.Severity))
+		incident.ClosedUntil = &until
+	}
+
+	tx := db.Begin()
+
+	if err := tx.Create(&incident).Error; err != nil {
+		tx.Rollback()
+		return incident, err
+	}
+
+	if incident.Cost > 0 {
+		var company models.Company
+		if err := tx.First(&company, incident.CompanyID).Error; err != nil {
+			tx.Rollback()
+			return incident, err
+		}
+		description := fmt.Sprintf("Incident: %s on %s", strings.ReplaceAll(incident.Type, "_", " "), trip.Route.Name)
+		if err := recordTransaction(tx, &company, "incident", description, -incident.Cost); err != nil {
+			tx.Rollback()
+			return incident, err
+		}
+	}
+
+	if err := fileInsuranceClaims(tx, incident, trip.Bus); err != nil {
+		tx.Rollback()
+		return incident, err
+	}
+
+	// Injured passengers are compensated even when the company cannot afford
+	// it; its parked buses are sold to cover the shortfall
+	if incident.Compensation > 0 {
+		var company models.Company
+		if err := tx.First(&company, incident.CompanyID).Error; err != nil {
+			tx.Rollback()
+			return incident, err
+		}
+		if company.Money < 0 {
+			if _, err := seizeBuses(tx, &company, -company.Money, "Bus sold to pay accident compensation: "); err != nil {
+				tx.Rollback()
+				return incident, err
+			}
+		}
+	}
+
+	return incident, tx.Commit().Error
+}
+
+// activeRoadClosure returns the incident closing a route at now, if any.
+func activeRoadClosure(db *gorm.DB, routeID uint, now time.Time) (models.Incident, bool) {
+	var incident models.Incident
+	err := db.Where("route_id = ? AND road_closed = ? AND closed_until > ?", routeID, true, now).
+		Order("closed_until DESC").First(&incident).Error
+	return incident, err == nil
+}
+
+// processRoadClosures reopens the roads whose closure period is over.
+func (w *World) processRoadClosures(now time.Time) {
+	var incidents []models.Incident
+	if err := w.db.Where("road_closed = ? AND (closed_until IS NULL OR closed_until <= ?)", true, now).Find(&incidents).Error; err != nil {
+		log.Printf("Failed to fetch road closures: %v", err)
+		return
+	}
+	for i := range incidents {
+		incidents[i].RoadClosed = false
+		if err := w.db.Model(&incidents[i]).Update("road_closed", false).Error; err != nil {
+			log.Printf("Failed to reopen road after incident %d: %v", incidents[i].ID, err)
+			continue
+		}
+		w.hub.broadcast <- WSMessage{Type: "road_reopened", Data: incidents[i]}
+	}
+}
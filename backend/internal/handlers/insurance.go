@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	insuranceBillingPeriod = 7 * simulation.GameDay
+	claimHistoryPeriod     = 365 * simulation.GameDay
+)
+
+type BuyInsuranceRequest struct {
+	BusID    uint   `json:"bus_id" binding:"required"`
+	Coverage string `json:"coverage" binding:"required"`
+}
+
+// InsuranceQuote is the price of a coverage for a bus.
+type InsuranceQuote struct {
+	simulation.InsuranceProduct
+	Premium      float64 `json:"premium"` // IDR per week
+	RecentClaims int     `json:"recent_claims"`
+	Insured      bool    `json:"insured"`
+}
+
+func (h *GameHandler) GetInsuranceQuote(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Query("bus_id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	quotes := make([]InsuranceQuote, 0, len(simulation.InsuranceProducts))
+	for _, product := range simulation.InsuranceProducts {
+		claims := recentClaims(h.db, company.ID, product.Coverage, simulation.Now())
+		var count int64
+		h.db.Model(&models.InsurancePolicy{}).
+			Where("bus_id = ? AND coverage = ? AND status = ?", bus.ID, product.Coverage, "active").Count(&count)
+		quotes = append(quotes, InsuranceQuote{
+			InsuranceProduct: product,
+			Premium:          simulation.WeeklyPremium(product.Coverage, busMarketValue(bus), bus.Capacity, claims),
+			RecentClaims:     claims,
+			Insured:          count > 0,
+		})
+	}
+
+	c.JSON(http.StatusOK, quotes)
+}
+
+func (h *GameHandler) GetInsurancePolicies(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var policies []models.InsurancePolicy
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Bus").Order("id DESC").Find(&policies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *GameHandler) BuyInsurance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req BuyInsuranceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, ok := simulation.FindInsuranceProduct(req.Coverage)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown coverage"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", req.BusID, company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	now := simulation.Now()
+	premium := simulation.WeeklyPremium(product.Coverage, busMarketValue(bus), bus.Capacity, recentClaims(h.db, company.ID, product.Coverage, now))
+	policy := models.InsurancePolicy{
+		CompanyID:     company.ID,
+		BusID:         bus.ID,
+		Coverage:      product.Coverage,
+		Premium:       premium,
+		Deductible:    product.Deductible,
+		Limit:         product.Limit,
+		Status:        "active",
+		StartedAt:     now,
+		NextBillingAt: now.Add(insuranceBillingPeriod),
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// The bus lock keeps two purchases of the same coverage apart;
+		// idx_active_policy backs it up
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bus, bus.ID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Model(&models.InsurancePolicy{}).
+			Where("bus_id = ? AND coverage = ? AND status = ?", bus.ID, product.Coverage, "active").Count(&count)
+		if count > 0 {
+			return refuse(http.StatusConflict, "Bus already has this coverage")
+		}
+
+		if err := tx.Create(&policy).Error; err != nil {
+			return err
+		}
+
+		// The first week is billed up front
+		return refusePayment(spendFunds(tx, &company, "insurance", fmt.Sprintf("%s insurance premium: %s", product.Name, bus.Name), premium),
+			"Insufficient funds for the first premium")
+	})
+	if err != nil {
+		respondActionError(c, err, "Failed to create policy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+func (h *GameHandler) CancelInsurance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var policy models.InsurancePolicy
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").First(&policy).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active policy not found"})
+		return
+	}
+
+	// Premiums already paid are not refunded
+	now := simulation.Now()
+	result := h.db.Model(&models.InsurancePolicy{}).Where("id = ? AND status = ?", policy.ID, "active").
+		Updates(map[string]interface{}{"status": "cancelled", "ended_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel policy"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active policy not found"})
+		return
+	}
+	policy.Status = "cancelled"
+	policy.EndedAt = &now
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// processInsurance bills the weekly premium of every policy that is due,
+// repricing it from the company's claim history. Policies the company cannot
+// pay for lapse.
+func (w *World) processInsurance(now time.Time) {
+	var policies []models.InsurancePolicy
+	if err := w.db.Where("status = ? AND next_billing_at <= ?", "active", now).Preload("Bus").Find(&policies).Error; err != nil {
+		log.Printf("Failed to fetch due insurance policies: %v", err)
+		return
+	}
+
+	for i := range policies {
+		if err := w.db.Transaction(func(tx *gorm.DB) error {
+			return billPremium(tx, &policies[i], now)
+		}); err != nil {
+			log.Printf("Failed to bill insurance policy %d: %v", policies[i].ID, err)
+		}
+	}
+}
+
+func billPremium(tx *gorm.DB, policy *models.InsurancePolicy, now time.Time) error {
+	// Reload the policy under a lock; it may have been cancelled since it was
+	// found due
+	bus := policy.Bus
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND next_billing_at <= ?", policy.ID, "active", now).
+		First(policy).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var company models.Company
+	if err := tx.First(&company, policy.CompanyID).Error; err != nil {
+		return err
+	}
+
+	policy.Premium = simulation.WeeklyPremium(policy.Coverage, busMarketValue(bus), bus.Capacity,
+		recentClaims(tx, policy.CompanyID, policy.Coverage, now))
+	policy.NextBillingAt = policy.NextBillingAt.Add(insuranceBillingPeriod)
+
+	// Policies lapse when the premium cannot be paid
+	product, _ := simulation.FindInsuranceProduct(policy.Coverage)
+	description := fmt.Sprintf("%s insurance premium: %s", product.Name, bus.Name)
+	if err := spendFunds(tx, &company, "insurance", description, policy.Premium); errors.Is(err, errInsufficientFunds) {
+		policy.Status = "lapsed"
+		policy.EndedAt = &now
+	} else if err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.InsurancePolicy{}).Where("id = ? AND status = ?", policy.ID, "active").
+		Updates(map[string]interface{}{
+			"premium":         policy.Premium,
+			"next_billing_at": policy.NextBillingAt,
+			"status":          policy.Status,
+			"ended_at":        policy.EndedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("insurance policy %d is no longer active", policy.ID)
+	}
+	return nil
+}
+
+// fileInsuranceClaims claims the insurable parts of an incident's cost against
+// the bus's active policies and credits the payouts.
+func fileInsuranceClaims(tx *gorm.DB, incident models.Incident, bus models.Bus) error {
+	amounts := simulation.ClaimableAmounts(incident.Type, incident.Cost, incident.Compensation)
+	for _, coverage := range []string{simulation.CoverageCollision, simulation.CoverageTowing, simulation.CoverageLiability} {
+		amount, ok := amounts[coverage]
+		if !ok {
+			continue
+		}
+
+		claim := models.InsuranceClaim{
+			IncidentID: incident.ID,
+			Coverage:   coverage,
+			CompanyID:  incident.CompanyID,
+			Amount:     amount,
+			Status:     "uninsured",
+		}
+
+		var policy models.InsurancePolicy
+		if err := tx.Where("bus_id = ? AND coverage = ? AND status = ?", bus.ID, coverage, "active").First(&policy).Error; err == nil {
+			limit := policy.Limit
+			if limit == 0 {
+				limit = busMarketValue(bus)
+			}
+			claim.PolicyID = &policy.ID
+			claim.Deductible = policy.Deductible
+			claim.Payout = simulation.ClaimPayout(amount, policy.Deductible, limit)
+			claim.Status = "rejected" // below the deductible
+			if claim.Payout > 0 {
+				claim.Status = "paid"
+			}
+		}
+
+		if err := tx.Create(&claim).Error; err != nil {
+			return err
+		}
+
+		if claim.Payout > 0 {
+			var company models.Company
+			if err := tx.First(&company, incident.CompanyID).Error; err != nil {
+				return err
+			}
+			description := fmt.Sprintf("Insurance payout: %s claim for %s", coverage, strings.ReplaceAll(incident.Type, "_", " "))
+			if err := recordTransaction(tx, &company, "insurance", description, claim.Payout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recentClaims counts the paid claims a company made on a coverage over the
+// last game year.
+func recentClaims(db *gorm.DB, companyID uint, coverage string, now time.Time) int {
+	var count int64
+	db.Model(&models.InsuranceClaim{}).
+		Joins("JOIN incidents ON incidents.id = insurance_claims.incident_id").
+		Where("insurance_claims.company_id = ? AND insurance_claims.coverage = ? AND insurance_claims.status = ?", companyID, coverage, "paid").
+		Where("incidents.game_time >= ?", now.Add(-claimHistoryPeriod)).
+		Count(&count)
+	return int(count)
+}
+
+// endBusPolicies cancels the policies of a bus leaving the company's fleet.
+func endBusPolicies(tx *gorm.DB, busID uint) error {
+	return tx.Model(&models.InsurancePolicy{}).Where("bus_id = ? AND status = ?", busID, "active").
+		Updates(map[string]interface{}{
+			"status":   "cancelled",
+			"ended_at": simulation.Now(),
+		}).Error
+}
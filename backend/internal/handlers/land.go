@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"bus-manager/internal/datapack"
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBaseLandPrice = 300000000.0 // IDR, used when a region does not set base_land_price
+	cityLandPremium      = 2.0         // extra multiples of the base price at a city centre
+	cityInfluenceKm      = 20.0        // distance over which the city premium decays
+)
+
+// LandQuote describes whether a depot can be built at a location and what the
+// land costs.
+type LandQuote struct {
+	Allowed     bool    `json:"allowed"`
+	Reason      string  `json:"reason,omitempty"`
+	Region      string  `json:"region,omitempty"`
+	NearestCity string  `json:"nearest_city,omitempty"`
+	DistanceKm  float64 `json:"distance_km"`
+	Price       float64 `json:"price"`
+}
+
+func (h *GameHandler) QuoteDepotLand(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("latitude"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("longitude"), 64)
+	if errLat != nil || errLng != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude and longitude are required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quoteLand(h.db, geo.Point{Lat: lat, Lng: lng}))
+}
+
+// quoteLand validates a depot location against the land of the data packs and
+// prices it by proximity to the nearest catalog city.
+func quoteLand(db *gorm.DB, p geo.Point) LandQuote {
+	region, ok := landRegionAt(p)
+	if !ok {
+		return LandQuote{Allowed: false, Reason: "Depots must be built on land in a supported region"}
+	}
+
+	quote := LandQuote{Allowed: true}
+	quote.Region, _ = region.Properties["name"].(string)
+
+	basePrice := defaultBaseLandPrice
+	if price, ok := region.Properties["base_land_price"].(float64); ok && price > 0 {
+		basePrice = price
+	}
+
+	premium := 0.0
+	var cities []models.City
+	db.Find(&cities)
+	for i, city := range cities {
+		distance := geo.Haversine(p, geo.Point{Lat: city.Latitude, Lng: city.Longitude})
+		if i == 0 || distance < quote.DistanceKm {
+			quote.NearestCity = city.Name
+			quote.DistanceKm = distance
+			premium = cityLandPremium * math.Exp(-distance/cityInfluenceKm)
+		}
+	}
+
+	quote.DistanceKm = math.Round(quote.DistanceKm*10) / 10
+	quote.Price = math.Round(basePrice*(1+premium)/1000) * 1000
+	return quote
+}
+
+func landRegionAt(p geo.Point) (geo.PolygonFeature, bool) {
+	for _, region := range datapack.Current().Land {
+		if region.Contains(p) {
+			return region, true
+		}
+	}
+	return geo.PolygonFeature{}, false
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	// Leaderboards are rebuilt from Postgres once their sorted set expires
+	leaderboardTTL        = 5 * time.Minute
+	defaultLeaderboardLen = 20
+	maxLeaderboardLen     = 100
+	leaderboardMonth      = 30 * simulation.GameDay
+)
+
+// operatingLedgerTypes are the ledger entries of running the business, which
+// make up the monthly profit. Capital, loans, asset sales and purchases and
+// game rewards are left out.
+var operatingLedgerTypes = []string{
+	"fare", "cargo", "charter", "subsidy", "advertising", "insurance",
+	"operating", "fuel", "electricity", "toll", "ferry", "incident",
+	"carbon_tax", "penalty", "marketing",
+}
+
+// leaderboardScores computes the score of every ranked company for one kind
+// of leaderboard. routeID is only used by per-route leaderboards.
+type leaderboardScores func(db *gorm.DB, routeID uint) (map[uint]float64, error)
+
+var leaderboards = map[string]leaderboardScores{
+	"net_worth":      netWorthScores,
+	"monthly_profit": monthlyProfitScores,
+	"passengers":     passengerScores,
+	"reputation":     reputationScores,
+	"market_share":   marketShareScores,
+}
+
+// LeaderboardEntry is one ranked company.
+type LeaderboardEntry struct {
+	Rank        int64   `json:"rank"`
+	CompanyID   uint    `json:"company_id"`
+	CompanyName string  `json:"company_name"`
+	Score       float64 `json:"score"`
+}
+
+func (h *GameHandler) GetLeaderboard(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	kind := c.Param("kind")
+	scores, ok := leaderboards[kind]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown leaderboard"})
+		return
+	}
+
+	key := "leaderboard:" + kind
+	var routeID uint
+	if kind == "market_share" {
+		id, err := strconv.ParseUint(c.Query("route_id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "route_id is required for market share"})
+			return
+		}
+		var route models.Route
+		if err := h.db.First(&route, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+			return
+		}
+		routeID = route.ID
+		key = fmt.Sprintf("leaderboard:market_share:%d", routeID)
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive number"})
+		return
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultLeaderboardLen)))
+	if err != nil || perPage < 1 || perPage > maxLeaderboardLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "per_page must be between 1 and 100"})
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.ensureLeaderboard(ctx, key, scores, routeID); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Leaderboards are unavailable"})
+		return
+	}
+
+	total, err := h.rdb.ZCard(ctx, key).Result()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Leaderboards are unavailable"})
+		return
+	}
+
+	start := int64((page - 1) * perPage)
+	ranked, err := h.rdb.ZRevRangeWithScores(ctx, key, start, start+int64(perPage)-1).Result()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Leaderboards are unavailable"})
+		return
+	}
+
+	ids := make([]uint, 0, len(ranked))
+	for _, z := range ranked {
+		id, _ := strconv.ParseUint(z.Member.(string), 10, 64)
+		ids = append(ids, uint(id))
+	}
+	var companies []models.Company
+	h.db.Where("id IN ?", ids).Find(&companies)
+	names := make(map[uint]string, len(companies))
+	for _, company := range companies {
+		names[company.ID] = company.Name
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(ranked))
+	for i, z := range ranked {
+		entries = append(entries, LeaderboardEntry{
+			Rank:        start + int64(i) + 1,
+			CompanyID:   ids[i],
+			CompanyName: names[ids[i]],
+			Score:       z.Score,
+		})
+	}
+
+	response := gin.H{
+		"kind":     kind,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+		"entries":  entries,
+		"me":       nil,
+	}
+	if routeID != 0 {
+		response["route_id"] = routeID
+	}
+
+	// The caller's own position, wherever it is on the board
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err == nil {
+		member := strconv.FormatUint(uint64(company.ID), 10)
+		if rank, err := h.rdb.ZRevRank(ctx, key, member).Result(); err == nil {
+			score, _ := h.rdb.ZScore(ctx, key, member).Result()
+			response["me"] = LeaderboardEntry{
+				Rank:        rank + 1,
+				CompanyID:   company.ID,
+				CompanyName: company.Name,
+				Score:       score,
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ensureLeaderboard rebuilds a leaderboard's sorted set from Postgres when it
+// has expired. Redis drops empty sorted sets, so a marker key records when the
+// board was built and empty boards are cached too.
+func (h *GameHandler) ensureLeaderboard(ctx context.Context, key string, scores leaderboardScores, routeID uint) error {
+	exists, err := h.rdb.Exists(ctx, key+":built").Result()
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	values, err := scores(h.db, routeID)
+	if err != nil {
+		return err
+	}
+
+	members := make([]*redis.Z, 0, len(values))
+	for companyID, score := range values {
+		members = append(members, &redis.Z{Score: score, Member: strconv.FormatUint(uint64(companyID), 10)})
+	}
+
+	// The marker is set first, so it never outlives the board it marks
+	pipe := h.rdb.TxPipeline()
+	pipe.Set(ctx, key+":built", 1, leaderboardTTL)
+	pipe.Del(ctx, key)
+	if len(members) > 0 {
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, leaderboardTTL)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// netWorthScores values each company's assets minus its outstanding loans.
+func netWorthScores(db *gorm.DB, _ uint) (map[uint]float64, error) {
+	var companies []models.Company
+	if err := db.Find(&companies).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[uint]float64, len(companies))
+	for _, company := range companies {
+		value, debt, _ := companyCredit(db, company)
+		scores[company.ID] = value - debt
+	}
+	return scores, nil
+}
+
+// monthlyProfitScores sums each company's operating ledger entries over the
+// last game month.
+func monthlyProfitScores(db *gorm.DB, _ uint) (map[uint]float64, error) {
+	since := time.Now().Add(-simulation.RealDuration(leaderboardMonth))
+	var rows []struct {
+		CompanyID uint
+		Total     float64
+	}
+	err := db.Model(&models.Transaction{}).
+		Select("company_id, SUM(amount) AS total").
+		Where("type IN ? AND created_at >= ?", operatingLedgerTypes, since).
+		Group("company_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		scores[row.CompanyID] = row.Total
+	}
+	return scores, nil
+}
+
+// passengerScores counts every passenger a company has carried.
+func passengerScores(db *gorm.DB, _ uint) (map[uint]float64, error) {
+	return tripTotals(db, "SUM(trips.passengers)", "1 = 1")
+}
+
+func reputationScores(db *gorm.DB, _ uint) (map[uint]float64, error) {
+	var companies []models.Company
+	if err := db.Find(&companies).Error; err != nil {
+		return nil, err
+	}
+	scores := make(map[uint]float64, len(companies))
+	for _, company := range companies {
+		scores[company.ID] = float64(company.Reputation)
+	}
+	return scores, nil
+}
+
+// marketShareScores is each company's percentage of the passengers carried on
+// a route over the last game month.
+func marketShareScores(db *gorm.DB, routeID uint) (map[uint]float64, error) {
+	since := time.Now().Add(-simulation.RealDuration(leaderboardMonth))
+	scores, err := tripTotals(db, "SUM(trips.passengers)", "trips.route_id = ? AND trips.actual_end >= ?", routeID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, passengers := range scores {
+		total += passengers
+	}
+	for companyID, passengers := range scores {
+		if total > 0 {
+			scores[companyID] = passengers / total * 100
+		}
+	}
+	return scores, nil
+}
+
+// tripTotals aggregates completed trips per operating company, which is the
+// company that dispatched the trip even if the bus has since changed hands.
+func tripTotals(db *gorm.DB, aggregate, condition string, args ...interface{}) (map[uint]float64, error) {
+	var rows []struct {
+		CompanyID uint
+		Total     float64
+	}
+	err := db.Table("trips").
+		Select("trips.company_id AS company_id, COALESCE("+aggregate+", 0) AS total").
+		Where("trips.status = ?", "completed").
+		Where(condition, args...).
+		Group("trips.company_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		scores[row.CompanyID] = row.Total
+	}
+	return scores, nil
+}
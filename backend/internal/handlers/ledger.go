@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"bus-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errInsufficientFunds is returned by spendFunds when the company cannot
+// afford a payment.
+var errInsufficientFunds = errors.New("insufficient funds")
+
+// recordTransaction adjusts the company balance by amount (negative for
+// expenses) and writes the matching ledger entry. Run it inside the caller's
+// database transaction so the balance and the ledger stay consistent. The
+// change is applied in the database, so concurrent postings to the same
+// company are never lost; company.Money is refreshed with the new balance.
+func recordTransaction(tx *gorm.DB, company *models.Company, txType, description string, amount float64) error {
+	if err := tx.Model(&models.Company{}).Where("id = ?", company.ID).
+		Update("money", gorm.Expr("money + ?", amount)).Error; err != nil {
+		return err
+	}
+	return writeLedgerEntry(tx, company, txType, description, amount)
+}
+
+// spendFunds is recordTransaction for payments the company must be able to
+// afford: it debits cost only while the balance covers it and returns
+// errInsufficientFunds otherwise, checking and debiting in one statement.
+func spendFunds(tx *gorm.DB, company *models.Company, txType, description string, cost float64) error {
+	result := tx.Model(&models.Company{}).Where("id = ? AND money >= ?", company.ID, cost).
+		Update("money", gorm.Expr("money - ?", cost))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInsufficientFunds
+	}
+	return writeLedgerEntry(tx, company, txType, description, -cost)
+}
+
+// respondPaymentError answers a request whose payment failed: a company that
+// cannot afford it gets message, anything else is a server error.
+func respondPaymentError(c *gin.Context, err error, message string) {
+	if errors.Is(err, errInsufficientFunds) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
+}
+
+func writeLedgerEntry(tx *gorm.DB, company *models.Company, txType, description string, amount float64) error {
+	if err := tx.Model(&models.Company{}).Select("money").Where("id = ?", company.ID).Scan(&company.Money).Error; err != nil {
+		return err
+	}
+
+	transaction := models.Transaction{
+		CompanyID:   company.ID,
+		Type:        txType,
+		Description: description,
+		Amount:      amount,
+		Balance:     company.Money,
+	}
+	return tx.Create(&transaction).Error
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	loanMinAmount         = 100000.0 // IDR, matches the smallest loan TakeLoanRequest accepts
+	loanMinWeeks          = 4
+	loanMaxWeeks          = 52
+	loanPeriod            = 7 * simulation.GameDay
+	loanLatePenaltyRatio  = 0.05 // share of a missed installment added to the balance
+	loanDefaultMisses     = 3    // consecutive missed installments before the bank seizes assets
+	loanEarlyRepaymentFee = 0.01 // share of the balance charged for early repayment
+)
+
+type TakeLoanRequest struct {
+	Amount float64 `json:"amount" binding:"required,min=100000"`
+	Weeks  int     `json:"weeks" binding:"required,min=4,max=52"`
+}
+
+type ScheduledPayment struct {
+	Number    int       `json:"number"`
+	DueAt     time.Time `json:"due_at"`
+	Payment   float64   `json:"payment"`
+	Interest  float64   `json:"interest"`
+	Principal float64   `json:"principal"`
+	Balance   float64   `json:"balance"`
+}
+
+func (h *GameHandler) GetLoanOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	value, debt, score := companyCredit(h.db, company)
+	maxAmount, rate := simulation.LoanLimit(value, debt, score)
+
+	c.JSON(http.StatusOK, gin.H{
+		"company_value":    math.Round(value),
+		"outstanding_debt": math.Round(debt),
+		"credit_score":     score,
+		"credit_grade":     simulation.CreditGrade(score),
+		"max_amount":       maxAmount,
+		"annual_rate":      rate,
+		"min_weeks":        loanMinWeeks,
+		"max_weeks":        loanMaxWeeks,
+	})
+}
+
+func (h *GameHandler) GetLoans(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var loans []models.Loan
+	if err := h.db.Where("company_id = ?", company.ID).Order("created_at DESC").Find(&loans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch loans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
+
+func (h *GameHandler) TakeLoan(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req TakeLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loan, err := h.takeLoan(&company, req.Amount, req.Weeks)
+	if err != nil {
+		respondActionError(c, err, "Failed to create loan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// takeLoan borrows amount for the company over weeks at the rate its credit
+// earns, up to the bank's limit.
+func (h *GameHandler) takeLoan(company *models.Company, amount float64, weeks int) (models.Loan, error) {
+	var loan models.Loan
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the company so concurrent loans are checked against the limit
+		// one after another
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(company, company.ID).Error; err != nil {
+			return err
+		}
+		value, debt, score := companyCredit(tx, *company)
+		maxAmount, rate := simulation.LoanLimit(value, debt, score)
+		if amount > maxAmount {
+			return refuse(http.StatusBadRequest, fmt.Sprintf("The bank will lend at most %.0f IDR (credit grade %s)", maxAmount, simulation.CreditGrade(score)))
+		}
+
+		now := simulation.Now()
+		loan = models.Loan{
+			CompanyID:         company.ID,
+			Principal:         amount,
+			AnnualRate:        rate,
+			Installments:      weeks,
+			InstallmentAmount: math.Ceil(simulation.Installment(amount, rate/52, weeks)),
+			Balance:           amount,
+			Status:            "active",
+			TakenAt:           now,
+			NextDueAt:         now.Add(loanPeriod),
+		}
+		if err := tx.Create(&loan).Error; err != nil {
+			return err
+		}
+		return recordTransaction(tx, company, "income", fmt.Sprintf("Loan #%d disbursement", loan.ID), amount)
+	})
+	return loan, err
+}
+
+func (h *GameHandler) GetLoanSchedule(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var loan models.Loan
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&loan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loan not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"loan":     loan,
+		"schedule": loanSchedule(loan),
+	})
+}
+
+func (h *GameHandler) RepayLoan(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var loan models.Loan
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the loan so an installment collected in between is not undone
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").First(&loan).Error; err != nil {
+			return refuse(http.StatusNotFound, "Active loan not found")
+		}
+
+		fee := math.Round(loan.Balance * loanEarlyRepaymentFee)
+		if err := spendFunds(tx, &company, "expense", fmt.Sprintf("Loan #%d early repayment", loan.ID), loan.Balance); err != nil {
+			return refusePayment(err, "Insufficient funds")
+		}
+		if err := spendFunds(tx, &company, "expense", fmt.Sprintf("Loan #%d early repayment fee", loan.ID), fee); err != nil {
+			return refusePayment(err, "Insufficient funds")
+		}
+
+		now := simulation.Now()
+		loan.Balance = 0
+		loan.Status = "repaid"
+		loan.ClosedAt = &now
+		return updateActiveLoan(tx, &loan)
+	})
+	if err != nil {
+		respondActionError(c, err, "Failed to update loan")
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// processLoans collects every installment that has fallen due.
+func (w *World) processLoans(now time.Time) {
+	var loans []models.Loan
+	if err := w.db.Where("status = ? AND next_due_at <= ?", "active", now).Find(&loans).Error; err != nil {
+		log.Printf("Failed to fetch due loans: %v", err)
+		return
+	}
+
+	for i := range loans {
+		if err := w.db.Transaction(func(tx *gorm.DB) error {
+			return collectInstallment(tx, &loans[i], now)
+		}); err != nil {
+			log.Printf("Failed to collect installment for loan %d: %v", loans[i].ID, err)
+		}
+	}
+}
+
+func collectInstallment(tx *gorm.DB, loan *models.Loan, now time.Time) error {
+	// Reload the loan under a lock so a repayment made since it was fetched is
+	// neither undone nor charged again
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND next_due_at <= ?", loan.ID, "active", now).First(loan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Lock the company so its balance cannot change between the check and the payment
+	var company models.Company
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&company, loan.CompanyID).Error; err != nil {
+		return err
+	}
+
+	interest := math.Round(loan.Balance * loan.AnnualRate / 52)
+	principal := math.Max(0, math.Min(loan.InstallmentAmount-interest, loan.Balance))
+	loan.NextDueAt = loan.NextDueAt.Add(loanPeriod)
+
+	if company.Money >= interest+principal {
+		if err := recordTransaction(tx, &company, "expense", fmt.Sprintf("Loan #%d interest", loan.ID), -interest); err != nil {
+			return err
+		}
+		if err := recordTransaction(tx, &company, "expense", fmt.Sprintf("Loan #%d principal repayment", loan.ID), -principal); err != nil {
+			return err
+		}
+		loan.Balance -= principal
+		loan.PaymentsMade++
+		loan.MissedInARow = 0
+		if loan.Balance < 1 {
+			loan.Balance = 0
+			loan.Status = "repaid"
+			loan.ClosedAt = &now
+		}
+		return updateActiveLoan(tx, loan)
+	}
+
+	// Missed installments accrue a late penalty on the balance
+	loan.PaymentsMissed++
+	loan.MissedInARow++
+	loan.Balance += math.Round((interest + principal) * loanLatePenaltyRatio)
+	if loan.MissedInARow >= loanDefaultMisses {
+		if err := seizeAssets(tx, loan, &company); err != nil {
+			return err
+		}
+		loan.Status = "defaulted"
+		loan.ClosedAt = &now
+	}
+	return updateActiveLoan(tx, loan)
+}
+
+// updateActiveLoan writes a loan's repayment state back while it is still
+// active.
+func updateActiveLoan(tx *gorm.DB, loan *models.Loan) error {
+	result := tx.Model(&models.Loan{}).Where("id = ? AND status = ?", loan.ID, "active").Updates(map[string]interface{}{
+		"balance":         loan.Balance,
+		"status":          loan.Status,
+		"payments_made":   loan.PaymentsMade,
+		"payments_missed": loan.PaymentsMissed,
+		"missed_in_a_row": loan.MissedInARow,
+		"next_due_at":     loan.NextDueAt,
+		"closed_at":       loan.ClosedAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("loan %d is no longer active", loan.ID)
+	}
+	return nil
+}
+
+// seizeAssets sells the defaulting company's parked buses at dealer prices and
+// takes whatever cash remains toward the outstanding balance.
+func seizeAssets(tx *gorm.DB, loan *models.Loan, company *models.Company) error {
+	proceeds, err := seizeBuses(tx, company, loan.Balance, "Bus seized by bank: ")
+	if err != nil {
+		return err
+	}
+	if payment := math.Min(proceeds, loan.Balance); payment > 0 {
+		if err := recordTransaction(tx, company, "expense", fmt.Sprintf("Loan #%d seizure proceeds", loan.ID), -payment); err != nil {
+			return err
+		}
+		loan.Balance -= payment
+	}
+
+	if cash := math.Min(math.Max(company.Money, 0), loan.Balance); cash > 0 {
+		if err := recordTransaction(tx, company, "expense", fmt.Sprintf("Loan #%d default settlement", loan.ID), -cash); err != nil {
+			return err
+		}
+		loan.Balance -= cash
+	}
+
+	log.Printf("Loan %d defaulted for company %d, %.0f IDR written off", loan.ID, company.ID, loan.Balance)
+	return nil
+}
+
+// seizeBuses sells a company's parked buses at dealer prices, the most
+// expensive first, until the sales raise owed, and returns what they raised.
+func seizeBuses(tx *gorm.DB, company *models.Company, owed float64, description string) (float64, error) {
+	var buses []models.Bus
+	if err := tx.Where("company_id = ? AND status IN ?", company.ID, []string{"available", "maintenance", "charging"}).
+		Order("purchase_price DESC").Find(&buses).Error; err != nil {
+		return 0, err
+	}
+
+	raised := 0.0
+	for _, bus := range buses {
+		if raised >= owed {
+			break
+		}
+		proceeds := math.Round(busMarketValue(bus) * dealerPriceRatio)
+		if err := removeBusFromFleet(tx, bus); err != nil {
+			return raised, err
+		}
+		if err := recordTransaction(tx, company, "sale", description+bus.Name, proceeds); err != nil {
+			return raised, err
+		}
+		raised += proceeds
+	}
+	return raised, nil
+}
+
+// companyCredit returns a company's asset value, outstanding debt and credit score.
+func companyCredit(db *gorm.DB, company models.Company) (float64, float64, int) {
+	var loans []models.Loan
+	db.Where("company_id = ?", company.ID).Find(&loans)
+
+	debt := 0.0
+	made, missed := 0, 0
+	for _, loan := range loans {
+		if loan.Status == "active" {
+			debt += loan.Balance
+		}
+		made += loan.PaymentsMade
+		missed += loan.PaymentsMissed
+	}
+
+	value := companyAssetValue(db, company)
+	debtRatio := 0.0
+	if value > 0 {
+		debtRatio = debt / value
+	} else if debt > 0 {
+		debtRatio = 1
+	}
+	return value, debt, simulation.CreditScore(made, missed, debtRatio)
+}
+
+// companyAssetValue sums cash, the market value of the fleet and the resale
+// value of depots.
+func companyAssetValue(db *gorm.DB, company models.Company) float64 {
+	value := company.Money
+
+	var buses []models.Bus
+	db.Where("company_id = ?", company.ID).Find(&buses)
+	for _, bus := range buses {
+		value += busMarketValue(bus)
+	}
+
+	var depots []models.Depot
+	db.Where("company_id = ?", company.ID).Find(&depots)
+	for _, depot := range depots {
+		value += depot.Investment * depotResaleRatio
+	}
+
+	return value
+}
+
+func loanSchedule(loan models.Loan) []ScheduledPayment {
+	var schedule []ScheduledPayment
+	if loan.Status != "active" {
+		return schedule
+	}
+
+	balance := loan.Balance
+	dueAt := loan.NextDueAt
+	for n := 1; balance >= 1 && n <= loanMaxWeeks*2; n++ {
+		interest := math.Round(balance * loan.AnnualRate / 52)
+		principal := math.Max(0, math.Min(loan.InstallmentAmount-interest, balance))
+		balance -= principal
+		schedule = append(schedule, ScheduledPayment{
+			Number:    loan.PaymentsMade + n,
+			DueAt:     dueAt,
+			Payment:   interest + principal,
+			Interest:  interest,
+			Principal: principal,
+			Balance:   math.Max(balance, 0),
+		})
+		dueAt = dueAt.Add(loanPeriod)
+	}
+	return schedule
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errBuyerDepotFull = errors.New("buyer depot is at full capacity")
+
+type CreateListingRequest struct {
+	BusID       uint    `json:"bus_id" binding:"required"`
+	AskingPrice float64 `json:"asking_price" binding:"required,min=1"`
+}
+
+type CreateOfferRequest struct {
+	Amount  float64 `json:"amount" binding:"required,min=1"`
+	DepotID uint    `json:"depot_id" binding:"required"`
+}
+
+func (h *GameHandler) GetListings(c *gin.Context) {
+	var listings []models.BusListing
+	if err := h.db.Where("status = ?", "open").Preload("Bus").Order("created_at DESC").Find(&listings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, listings)
+}
+
+func (h *GameHandler) CreateListing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req CreateListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", req.BusID, company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	if bus.Status != "available" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only available buses can be listed"})
+		return
+	}
+
+	listing := models.BusListing{
+		SellerCompanyID: company.ID,
+		BusID:           bus.ID,
+		AskingPrice:     req.AskingPrice,
+		Status:          "open",
+	}
+
+	tx := h.db.Begin()
+
+	if err := tx.Create(&listing).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create listing"})
+		return
+	}
+
+	// Listed buses stay in the depot and cannot be dispatched
+	if err := tx.Model(&bus).Update("status", "listed").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bus"})
+		return
+	}
+
+	tx.Commit()
+
+	listing.Bus = bus
+	c.JSON(http.StatusCreated, listing)
+}
+
+func (h *GameHandler) CancelListing(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	var listing models.BusListing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND seller_company_id = ? AND status = ?", c.Param("id"), company.ID, "open").
+		First(&listing).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Open listing not found"})
+		return
+	}
+
+	if err := tx.Model(&listing).Update("status", "cancelled").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel listing"})
+		return
+	}
+
+	if err := tx.Model(&models.Bus{}).Where("id = ?", listing.BusID).Update("status", "available").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bus"})
+		return
+	}
+
+	if err := refundPendingOffers(tx, listing.ID, 0); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund offers"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Listing cancelled"})
+}
+
+func (h *GameHandler) GetOffers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var made []models.BusOffer
+	if err := h.db.Where("buyer_company_id = ?", company.ID).Preload("Listing.Bus").
+		Order("created_at DESC").Find(&made).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	var received []models.BusOffer
+	if err := h.db.Where("listing_id IN (SELECT id FROM bus_listings WHERE seller_company_id = ?) AND status = ?", company.ID, "pending").
+		Preload("Listing.Bus").Order("amount DESC").Find(&received).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"made":     made,
+		"received": received,
+	})
+}
+
+func (h *GameHandler) CreateOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req CreateOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var listing models.BusListing
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "open").First(&listing).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Open listing not found"})
+		return
+	}
+
+	if listing.SellerCompanyID == company.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot make an offer on your own listing"})
+		return
+	}
+
+	var existingOffer models.BusOffer
+	if err := h.db.Where("listing_id = ? AND buyer_company_id = ? AND status = ?", listing.ID, company.ID, "pending").
+		First(&existingOffer).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a pending offer on this listing"})
+		return
+	}
+
+	var depot models.Depot
+	if err := h.db.Where("id = ? AND company_id = ?", req.DepotID, company.ID).First(&depot).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Depot not found or not owned by company"})
+		return
+	}
+
+	if depot.CurrentBuses >= depot.Capacity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Depot is at full capacity"})
+		return
+	}
+
+	offer := models.BusOffer{
+		ListingID:      listing.ID,
+		BuyerCompanyID: company.ID,
+		DepotID:        depot.ID,
+		Amount:         req.Amount,
+		Status:         "pending",
+	}
+
+	tx := h.db.Begin()
+
+	if err := tx.Create(&offer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create offer"})
+		return
+	}
+
+	// Offers are held in escrow until the seller responds
+	if err := spendFunds(tx, &company, "purchase", "Bus offer escrow", req.Amount); err != nil {
+		tx.Rollback()
+		respondPaymentError(c, err, "Insufficient funds")
+		return
+	}
+
+	// Offers at or above the asking price buy the bus immediately
+	if offer.Amount >= listing.AskingPrice {
+		if err := completeBusSale(tx, listing.ID, &offer); err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Listing is no longer available"})
+			} else if errors.Is(err, errBuyerDepotFull) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Depot is at full capacity"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete purchase"})
+			}
+			return
+		}
+	}
+
+	tx.Commit()
+
+	if offer.Status == "accepted" {
+		h.publishBusPurchase(offer)
+	}
+
+	c.JSON(http.StatusCreated, offer)
+}
+
+func (h *GameHandler) AcceptOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var offer models.BusOffer
+	if err := h.db.Where("id = ? AND status = ? AND listing_id IN (SELECT id FROM bus_listings WHERE seller_company_id = ?)",
+		c.Param("id"), "pending", company.ID).First(&offer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending offer not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	if err := completeBusSale(tx, offer.ListingID, &offer); err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Offer or listing is no longer available"})
+		} else if errors.Is(err, errBuyerDepotFull) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The buyer's depot is full. Reject the offer or wait."})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete sale"})
+		}
+		return
+	}
+
+	tx.Commit()
+
+	if offer.Status == "accepted" {
+		h.publishBusPurchase(offer)
+	}
+
+	c.JSON(http.StatusOK, offer)
+}
+
+func (h *GameHandler) RejectOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	h.closeOffer(c, "listing_id IN (SELECT id FROM bus_listings WHERE seller_company_id = ?)", company.ID, "rejected")
+}
+
+func (h *GameHandler) WithdrawOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	h.closeOffer(c, "buyer_company_id = ?", company.ID, "withdrawn")
+}
+
+// closeOffer ends a pending offer the caller is party to and refunds the escrow.
+func (h *GameHandler) closeOffer(c *gin.Context, ownership string, companyID uint, status string) {
+	tx := h.db.Begin()
+
+	var offer models.BusOffer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", c.Param("id"), "pending").Where(ownership, companyID).
+		First(&offer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending offer not found"})
+		return
+	}
+
+	if err := refundOffer(tx, &offer, status); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund offer"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, offer)
+}
+
+// completeBusSale transfers the listed bus to the buyer of offer, pays the
+// seller from the escrowed amount and refunds every other pending offer. It
+// locks the listing and offer so concurrent accepts cannot sell a bus twice.
+func completeBusSale(tx *gorm.DB, listingID uint, offer *models.BusOffer) error {
+	var listing models.BusListing
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", listingID, "open").Preload("Bus").First(&listing).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", offer.ID, "pending").First(offer).Error; err != nil {
+		return err
+	}
+
+	var depot models.Depot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&depot, offer.DepotID).Error; err != nil {
+		return err
+	}
+	if depot.CurrentBuses >= depot.Capacity {
+		return errBuyerDepotFull
+	}
+
+	if err := tx.Model(&listing).Update("status", "sold").Error; err != nil {
+		return err
+	}
+	offer.Status = "accepted"
+	if err := tx.Model(offer).Update("status", offer.Status).Error; err != nil {
+		return err
+	}
+
+	// Move the bus between fleets; the seller's insurance does not transfer
+	if err := endBusPolicies(tx, listing.Bus.ID); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Depot{}).Where("id = ?", listing.Bus.DepotID).
+		Update("current_buses", gorm.Expr("current_buses - 1")).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&depot).Update("current_buses", gorm.Expr("current_buses + 1")).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&listing.Bus).Updates(map[string]interface{}{
+		"company_id": offer.BuyerCompanyID,
+		"depot_id":   depot.ID,
+		"status":     "available",
+	}).Error; err != nil {
+		return err
+	}
+
+	// Release the escrow to the seller
+	var seller models.Company
+	if err := tx.First(&seller, listing.SellerCompanyID).Error; err != nil {
+		return err
+	}
+	if err := recordTransaction(tx, &seller, "sale", "Sold bus on market: "+listing.Bus.Name, offer.Amount); err != nil {
+		return err
+	}
+
+	return refundPendingOffers(tx, listing.ID, offer.ID)
+}
+
+// refundPendingOffers rejects every pending offer on a listing except keepID.
+func refundPendingOffers(tx *gorm.DB, listingID, keepID uint) error {
+	var offers []models.BusOffer
+	if err := tx.Where("listing_id = ? AND status = ? AND id <> ?", listingID, "pending", keepID).Find(&offers).Error; err != nil {
+		return err
+	}
+	for i := range offers {
+		if err := refundOffer(tx, &offers[i], "rejected"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func refundOffer(tx *gorm.DB, offer *models.BusOffer, status string) error {
+	offer.Status = status
+	if err := tx.Model(offer).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	var buyer models.Company
+	if err := tx.First(&buyer, offer.BuyerCompanyID).Error; err != nil {
+		return err
+	}
+	return recordTransaction(tx, &buyer, "income", "Bus offer refund", offer.Amount)
+}
+
+// publishBusPurchase reports a bus bought on the used market to the buyer's achievements.
+func (h *GameHandler) publishBusPurchase(offer models.BusOffer) {
+	h.hub.PublishEvent(offer.BuyerCompanyID, simulation.GameEvent{
+		Type:   simulation.EventBusBought,
+		Values: map[string]float64{"price": offer.Amount, "used": 1},
+	})
+}
@@ -0,0 +1,601 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxCampaignDays          = 90
+	defaultMarketingDays     = 30
+	maxMarketingAnalysisDays = 365
+)
+
+type CreateCampaignRequest struct {
+	Channel string  `json:"channel" binding:"required"`
+	City    string  `json:"city"`                           // target the routes serving a city
+	RouteID uint    `json:"route_id"`                       // or a single route
+	Budget  float64 `json:"budget" binding:"required,gt=0"` // IDR over the whole campaign
+	Days    int     `json:"days" binding:"required,min=1"`
+}
+
+type SignAdvertisementRequest struct {
+	Advertiser string `json:"advertiser" binding:"required"`
+}
+
+func (h *GameHandler) GetCampaigns(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var campaigns []models.MarketingCampaign
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Route").Order("starts_at DESC").Find(&campaigns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaigns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels":  simulation.MarketingChannels,
+		"campaigns": campaigns,
+	})
+}
+
+func (h *GameHandler) CreateCampaign(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, ok := simulation.FindMarketingChannel(req.Channel)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown marketing channel: " + req.Channel})
+		return
+	}
+	if req.Days > maxCampaignDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Campaigns run for at most %d game days", maxCampaignDays)})
+		return
+	}
+
+	campaign := models.MarketingCampaign{
+		CompanyID: company.ID,
+		Channel:   channel.Channel,
+		Days:      req.Days,
+		Status:    "active",
+	}
+	switch {
+	case req.RouteID != 0 && req.City == "":
+		var route models.Route
+		if err := h.db.Where("id = ? AND status = ?", req.RouteID, "approved").First(&route).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Route not found"})
+			return
+		}
+		campaign.TargetType = simulation.TargetRoute
+		campaign.RouteID = &route.ID
+	case req.City != "" && req.RouteID == 0:
+		var city models.City
+		if err := h.db.Where("name = ?", req.City).First(&city).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "City not found"})
+			return
+		}
+		campaign.TargetType = simulation.TargetCity
+		campaign.City = city.Name
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target either a city or a route"})
+		return
+	}
+	if !channel.CanTarget(campaign.TargetType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s cannot target a %s", channel.Name, campaign.TargetType)})
+		return
+	}
+
+	campaign.DailyBudget = math.Round(req.Budget / float64(req.Days))
+	if campaign.DailyBudget < channel.MinDailyBudget {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s needs at least Rp %.0f per day", channel.Name, channel.MinDailyBudget)})
+		return
+	}
+	campaign.Boost = channel.CampaignBoost(campaign.DailyBudget)
+
+	now := simulation.Now()
+	campaign.StartsAt = now
+	campaign.EndsAt = now.Add(time.Duration(req.Days) * simulation.GameDay)
+
+	// The first day is paid up front, the rest every game midnight
+	campaign.DaysPaid = 1
+	campaign.Spent = campaign.DailyBudget
+
+	tx := h.db.Begin()
+
+	if err := tx.Create(&campaign).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create campaign"})
+		return
+	}
+
+	if err := spendFunds(tx, &company, "marketing", campaignDescription(campaign, channel), campaign.DailyBudget); err != nil {
+		tx.Rollback()
+		respondPaymentError(c, err, "Insufficient funds")
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+func (h *GameHandler) CancelCampaign(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var campaign models.MarketingCampaign
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").First(&campaign).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active campaign not found"})
+		return
+	}
+
+	// Days already paid for are not refunded
+	now := simulation.Now()
+	campaign.Status = "cancelled"
+	campaign.EndsAt = now
+	if err := h.db.Model(&campaign).Updates(map[string]interface{}{
+		"status":  campaign.Status,
+		"ends_at": campaign.EndsAt,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel campaign"})
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func campaignDescription(campaign models.MarketingCampaign, channel simulation.MarketingChannel) string {
+	if campaign.TargetType == simulation.TargetCity {
+		return fmt.Sprintf("%s campaign: %s", channel.Name, campaign.City)
+	}
+	return fmt.Sprintf("%s campaign: route #%d", channel.Name, *campaign.RouteID)
+}
+
+// marketingBoost is the combined demand boost of the company's running
+// campaigns on a route and the cities it serves.
+func marketingBoost(db *gorm.DB, companyID uint, route models.Route) float64 {
+	now := simulation.Now()
+	var boost float64
+	db.Model(&models.MarketingCampaign{}).
+		Select("COALESCE(SUM(boost), 0)").
+		Where("company_id = ? AND status = ? AND starts_at <= ? AND ends_at > ?", companyID, "active", now, now).
+		Where("route_id = ? OR city IN ?", route.ID, []string{route.Origin, route.Destination}).
+		Scan(&boost)
+	return math.Min(simulation.MaxMarketingBoost, boost)
+}
+
+// processMarketing charges running campaigns their daily budget and closes the
+// ones that ended. Campaigns the company can no longer pay for are cancelled.
+// It runs at game midnight.
+func (w *World) processMarketing(now time.Time) {
+	var campaigns []models.MarketingCampaign
+	if err := w.db.Where("status = ?", "active").Find(&campaigns).Error; err != nil {
+		log.Printf("Failed to fetch marketing campaigns: %v", err)
+		return
+	}
+
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		if !now.Before(campaign.EndsAt) || campaign.DaysPaid >= campaign.Days {
+			if !now.Before(campaign.EndsAt) {
+				w.db.Model(campaign).Update("status", "completed")
+			}
+			continue
+		}
+
+		var company models.Company
+		if err := w.db.First(&company, campaign.CompanyID).Error; err != nil {
+			continue
+		}
+
+		channel, _ := simulation.FindMarketingChannel(campaign.Channel)
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(campaign).Updates(map[string]interface{}{
+				"days_paid": campaign.DaysPaid + 1,
+				"spent":     campaign.Spent + campaign.DailyBudget,
+			}).Error; err != nil {
+				return err
+			}
+			return spendFunds(tx, &company, "marketing", campaignDescription(*campaign, channel), campaign.DailyBudget)
+		})
+		if errors.Is(err, errInsufficientFunds) {
+			w.db.Model(campaign).Updates(map[string]interface{}{"status": "cancelled", "ends_at": now})
+			w.hub.broadcast <- WSMessage{Type: "campaign_cancelled", Data: campaign, UserID: company.UserID}
+		} else if err != nil {
+			log.Printf("Failed to charge campaign %d: %v", campaign.ID, err)
+		}
+	}
+}
+
+func (h *GameHandler) GetAdOffers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	var current *models.BusAdvertisement
+	var ad models.BusAdvertisement
+	if err := h.db.Where("bus_id = ? AND status = ?", bus.ID, "active").First(&ad).Error; err == nil {
+		current = &ad
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"current": current,
+		"offers":  simulation.AdOffers(bus.ID, bus.Capacity, simulation.Now()),
+	})
+}
+
+func (h *GameHandler) SignAdvertisement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req SignAdvertisementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bus models.Bus
+	if err := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+		return
+	}
+
+	now := simulation.Now()
+	for _, offer := range simulation.AdOffers(bus.ID, bus.Capacity, now) {
+		if offer.Advertiser != req.Advertiser {
+			continue
+		}
+		ad := models.BusAdvertisement{
+			BusID:         bus.ID,
+			CompanyID:     company.ID,
+			Advertiser:    offer.Advertiser,
+			Weeks:         offer.Weeks,
+			WeeklyPayment: offer.WeeklyPayment,
+			Status:        "active",
+			StartsAt:      now,
+			NextPaymentAt: now.Add(simulation.AdvertisingWeek),
+		}
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			// The bus lock keeps two signings for the same livery apart;
+			// idx_active_advertisement backs it up
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bus, bus.ID).Error; err != nil {
+				return err
+			}
+
+			var active int64
+			tx.Model(&models.BusAdvertisement{}).Where("bus_id = ? AND status = ?", bus.ID, "active").Count(&active)
+			if active > 0 {
+				return refuse(http.StatusBadRequest, "The bus livery is already rented out")
+			}
+
+			return tx.Create(&ad).Error
+		})
+		if err != nil {
+			respondActionError(c, err, "Failed to sign advertisement")
+			return
+		}
+		c.JSON(http.StatusCreated, ad)
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "No offer from " + req.Advertiser + " for this bus"})
+}
+
+func (h *GameHandler) TerminateAdvertisement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var ad models.BusAdvertisement
+	if err := h.db.Where("bus_id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").First(&ad).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active advertisement on this bus"})
+		return
+	}
+
+	// Breaking the contract early forfeits one week's payment
+	tx := h.db.Begin()
+
+	ad.Status = "terminated"
+	if err := tx.Model(&ad).Update("status", ad.Status).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to terminate advertisement"})
+		return
+	}
+	if err := recordTransaction(tx, &company, "penalty", "Advertising contract terminated: "+ad.Advertiser, -ad.WeeklyPayment); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, ad)
+}
+
+// processAdvertising pays every advertisement that is due for the distance its
+// bus drove over the week. Advertisements end unpaid when the bus sat idle
+// all week or the company no longer owns it.
+func (w *World) processAdvertising(now time.Time) {
+	var ads []models.BusAdvertisement
+	if err := w.db.Where("status = ? AND next_payment_at <= ?", "active", now).Find(&ads).Error; err != nil {
+		log.Printf("Failed to fetch due advertisements: %v", err)
+		return
+	}
+
+	for i := range ads {
+		ad := &ads[i]
+		var bus models.Bus
+		if err := w.db.First(&bus, ad.BusID).Error; err != nil || bus.CompanyID != ad.CompanyID {
+			w.db.Model(ad).Update("status", "ended")
+			continue
+		}
+
+		// Advertisers walk away from a bus that never left the depot
+		if ad.WeekKm <= 0 {
+			ad.Status = "ended"
+			w.db.Model(ad).Update("status", ad.Status)
+			var company models.Company
+			if err := w.db.First(&company, ad.CompanyID).Error; err == nil {
+				w.hub.broadcast <- WSMessage{Type: "advertisement_ended", Data: ad, UserID: company.UserID}
+			}
+			continue
+		}
+
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			var company models.Company
+			if err := tx.First(&company, ad.CompanyID).Error; err != nil {
+				return err
+			}
+
+			// Distance logged after the week closed counts towards the next one
+			km := ad.WeekKm
+			payment := simulation.AdPayment(ad.WeeklyPayment, km)
+			ad.PaymentsMade++
+			ad.Earned += payment
+			ad.WeekKm = 0
+			ad.NextPaymentAt = ad.NextPaymentAt.Add(simulation.AdvertisingWeek)
+			if ad.PaymentsMade >= ad.Weeks {
+				ad.Status = "ended"
+			}
+			if err := tx.Model(ad).Updates(map[string]interface{}{
+				"payments_made":   ad.PaymentsMade,
+				"earned":          ad.Earned,
+				"week_km":         gorm.Expr("week_km - ?", km),
+				"next_payment_at": ad.NextPaymentAt,
+				"status":          ad.Status,
+			}).Error; err != nil {
+				return err
+			}
+
+			description := fmt.Sprintf("Livery advertising: %s on %s (week %d of %d, %.0f km)", ad.Advertiser, bus.Name, ad.PaymentsMade, ad.Weeks, km)
+			return recordTransaction(tx, &company, "advertising", description, payment)
+		})
+		if err != nil {
+			log.Printf("Failed to pay advertisement %d: %v", ad.ID, err)
+		}
+	}
+}
+
+// logAdvertisingKm adds the distance of a completed trip to the advertisement
+// on the bus, if any.
+func logAdvertisingKm(db *gorm.DB, busID uint, km float64) error {
+	return db.Model(&models.BusAdvertisement{}).
+		Where("bus_id = ? AND status = ?", busID, "active").
+		Update("week_km", gorm.Expr("week_km + ?", km)).Error
+}
+
+// CampaignAnalysis compares the targeted routes while a campaign ran with the
+// same length of time before it.
+type CampaignAnalysis struct {
+	models.MarketingCampaign
+	Trips                 int     `json:"trips"`
+	Passengers            int     `json:"passengers"`
+	Revenue               float64 `json:"revenue"`
+	PassengersPerTrip     float64 `json:"passengers_per_trip"`
+	BaselinePerTrip       float64 `json:"baseline_passengers_per_trip"`
+	Uplift                float64 `json:"uplift"` // change in passengers per trip, e.g. 0.12 for +12%
+	RevenuePerRupiahSpent float64 `json:"revenue_per_rupiah_spent"`
+}
+
+func (h *GameHandler) GetMarketingAnalytics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultMarketingDays)))
+	if err != nil || days < 1 || days > maxMarketingAnalysisDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxMarketingAnalysisDays)})
+		return
+	}
+
+	now := simulation.Now()
+	since := now.Add(-time.Duration(days) * simulation.GameDay)
+
+	var campaigns []models.MarketingCampaign
+	if err := h.db.Where("company_id = ? AND ends_at > ?", company.ID, since).Preload("Route").Order("starts_at DESC").Find(&campaigns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaigns"})
+		return
+	}
+
+	analyses := make([]CampaignAnalysis, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		end := campaign.EndsAt
+		if end.After(now) {
+			end = now
+		}
+		analysis := CampaignAnalysis{MarketingCampaign: campaign}
+		during := campaignTrips(h.db, company.ID, campaign, campaign.StartsAt, end)
+		before := campaignTrips(h.db, company.ID, campaign, campaign.StartsAt.Add(-end.Sub(campaign.StartsAt)), campaign.StartsAt)
+		analysis.Trips = during.Trips
+		analysis.Passengers = during.Passengers
+		analysis.Revenue = during.Revenue
+		if during.Trips > 0 {
+			analysis.PassengersPerTrip = float64(during.Passengers) / float64(during.Trips)
+		}
+		if before.Trips > 0 {
+			analysis.BaselinePerTrip = float64(before.Passengers) / float64(before.Trips)
+		}
+		if analysis.BaselinePerTrip > 0 && during.Trips > 0 {
+			analysis.Uplift = math.Round((analysis.PassengersPerTrip/analysis.BaselinePerTrip-1)*1000) / 1000
+		}
+		if campaign.Spent > 0 {
+			analysis.RevenuePerRupiahSpent = math.Round(during.Revenue/campaign.Spent*100) / 100
+		}
+		analyses = append(analyses, analysis)
+	}
+
+	var ads []models.BusAdvertisement
+	if err := h.db.Where("company_id = ? AND (status = ? OR updated_at >= ?)", company.ID, "active", realTimeOf(since)).
+		Order("starts_at DESC").Find(&ads).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch advertisements"})
+		return
+	}
+
+	var totals []struct {
+		Type  string
+		Total float64
+	}
+	h.db.Model(&models.Transaction{}).
+		Select("type, SUM(amount) AS total").
+		Where("company_id = ? AND type IN ? AND created_at >= ?", company.ID, []string{"marketing", "advertising"}, realTimeOf(since)).
+		Group("type").Scan(&totals)
+	spend, income := 0.0, 0.0
+	for _, t := range totals {
+		switch t.Type {
+		case "marketing":
+			spend = -t.Total
+		case "advertising":
+			income = t.Total
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":               days,
+		"marketing_spend":    spend,
+		"advertising_income": income,
+		"campaigns":          analyses,
+		"advertisements":     ads,
+	})
+}
+
+type tripSummary struct {
+	Trips      int
+	Passengers int
+	Revenue    float64
+}
+
+// campaignTrips sums the company's completed trips on the routes a campaign
+// targets between two game times.
+func campaignTrips(db *gorm.DB, companyID uint, campaign models.MarketingCampaign, from, to time.Time) tripSummary {
+	query := db.Table("trips").
+		Select("COUNT(*) AS trips, COALESCE(SUM(trips.passengers), 0) AS passengers, COALESCE(SUM(trips.revenue), 0) AS revenue").
+		Joins("JOIN routes ON routes.id = trips.route_id").
+		Where("trips.company_id = ? AND trips.status = ? AND trips.actual_end >= ? AND trips.actual_end < ?",
+			companyID, "completed", realTimeOf(from), realTimeOf(to))
+	if campaign.TargetType == simulation.TargetRoute {
+		query = query.Where("trips.route_id = ?", campaign.RouteID)
+	} else {
+		query = query.Where("routes.origin = ? OR routes.destination = ?", campaign.City, campaign.City)
+	}
+
+	var summary tripSummary
+	query.Scan(&summary)
+	return summary
+}
+
+// realTimeOf converts a game time to the wall-clock time it happened at, for
+// comparing with timestamps recorded in real time.
+func realTimeOf(gameTime time.Time) time.Time {
+	return time.Now().Add(-simulation.RealDuration(simulation.Now().Sub(gameTime)))
+}
@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	permitTermDays       = 30  // game days per permit term
+	permitRenewalDays    = 7   // renewal opens this many game days before expiry
+	permitPriceFareRatio = 500 // permit price as a multiple of the base fare when unset
+	maxPermitViolations  = 3   // permits are revoked at this many violations
+	minServiceCondition  = 50  // dispatching a bus below this condition is a violation
+)
+
+var (
+	errNoPermitSlot = errors.New("no permit slot available")
+	errBidNotOpen   = errors.New("bid is no longer open")
+)
+
+type PlacePermitBidRequest struct {
+	Amount float64 `json:"amount" binding:"required,min=0"`
+}
+
+type RevokePermitRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *GameHandler) GetPermits(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var permits []models.RoutePermit
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Route").Order("expires_at DESC").Find(&permits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permits"})
+		return
+	}
+
+	var bids []models.PermitBid
+	if err := h.db.Where("company_id = ? AND status = ?", company.ID, "open").Preload("Route").Find(&bids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch permit bids"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permits": permits,
+		"bids":    bids,
+	})
+}
+
+func (h *GameHandler) BuyPermit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var route models.Route
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "approved").First(&route).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+
+	permit, err := h.buyPermit(&company, route)
+	if err != nil {
+		respondActionError(c, err, "Failed to create permit")
+		return
+	}
+
+	c.JSON(http.StatusCreated, permit)
+}
+
+// buyPermit buys the company a permit for a route with a free slot.
+func (h *GameHandler) buyPermit(company *models.Company, route models.Route) (models.RoutePermit, error) {
+	if msg := permitEligibilityError(h.db, *company, route); msg != "" {
+		return models.RoutePermit{}, refuse(http.StatusBadRequest, msg)
+	}
+
+	price := permitPrice(route)
+
+	var permit models.RoutePermit
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the route so its free slots are counted one sale at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&route, route.ID).Error; err != nil {
+			return err
+		}
+		if countActivePermits(tx, route.ID) >= int64(route.PermitSlots) {
+			return refuse(http.StatusConflict, "No permit slots available on this route. Place a bid instead.")
+		}
+
+		var err error
+		if permit, err = grantPermit(tx, company.ID, route, price); err != nil {
+			return err
+		}
+		err = spendFunds(tx, company, "expense", "Route permit: "+route.Name, price)
+		return refusePayment(err, "Insufficient funds")
+	})
+	return permit, err
+}
+
+func (h *GameHandler) PlacePermitBid(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req PlacePermitBidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var route models.Route
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "approved").First(&route).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+
+	if msg := permitEligibilityError(h.db, company, route); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if countActivePermits(h.db, route.ID) < int64(route.PermitSlots) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Permit slots are available on this route. Buy a permit instead."})
+		return
+	}
+
+	var existingBid models.PermitBid
+	if err := h.db.Where("company_id = ? AND route_id = ? AND status = ?", company.ID, route.ID, "open").First(&existingBid).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have an open bid on this route"})
+		return
+	}
+
+	if req.Amount < permitPrice(route) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bid must be at least the permit price of %.0f IDR", permitPrice(route))})
+		return
+	}
+
+	bid := models.PermitBid{
+		CompanyID: company.ID,
+		RouteID:   route.ID,
+		Amount:    req.Amount,
+		Status:    "open",
+	}
+
+	tx := h.db.Begin()
+
+	if err := tx.Create(&bid).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to place bid"})
+		return
+	}
+
+	// Bids are held in escrow until they win or are withdrawn
+	if err := spendFunds(tx, &company, "expense", "Permit bid escrow: "+route.Name, req.Amount); err != nil {
+		tx.Rollback()
+		respondPaymentError(c, err, "Insufficient funds")
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, bid)
+}
+
+func (h *GameHandler) WithdrawPermitBid(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var bid models.PermitBid
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "open").
+		Preload("Route").First(&bid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Open bid not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Only an open bid is refunded; one awarded meanwhile keeps its escrow
+	bid.Status = "withdrawn"
+	result := tx.Model(&models.PermitBid{}).Where("id = ? AND status = ?", bid.ID, "open").Update("status", bid.Status)
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw bid"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Open bid not found"})
+		return
+	}
+
+	if err := recordTransaction(tx, &company, "income", "Permit bid refund: "+bid.Route.Name, bid.Amount); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company funds"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, bid)
+}
+
+func (h *GameHandler) RenewPermit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var permit models.RoutePermit
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").
+		Preload("Route").First(&permit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active permit not found"})
+		return
+	}
+
+	if err := h.renewPermit(&company, &permit); err != nil {
+		respondActionError(c, err, "Failed to renew permit")
+		return
+	}
+
+	c.JSON(http.StatusOK, permit)
+}
+
+type SetFareRequest struct {
+	FareMultiplier float64 `json:"fare_multiplier" binding:"required"` // multiple of the route tariff
+}
+
+func (h *GameHandler) SetPermitFare(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req SetFareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var permit models.RoutePermit
+	if err := h.db.Where("id = ? AND company_id = ? AND status = ?", c.Param("id"), company.ID, "active").
+		Preload("Route").First(&permit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active permit not found"})
+		return
+	}
+
+	if err := h.setFare(&company, &permit, req.FareMultiplier); err != nil {
+		respondActionError(c, err, "Failed to set fare")
+		return
+	}
+
+	c.JSON(http.StatusOK, permit)
+}
+
+// setFare sets the fare a company charges on a permitted route as a multiple
+// of the route tariff. It applies from the next departure.
+func (h *GameHandler) setFare(company *models.Company, permit *models.RoutePermit, multiplier float64) error {
+	if multiplier < simulation.MinFareMultiplier || multiplier > simulation.MaxFareMultiplier {
+		return refuse(http.StatusBadRequest, fmt.Sprintf("Fares must be between %.0f%% and %.0f%% of the route tariff",
+			simulation.MinFareMultiplier*100, simulation.MaxFareMultiplier*100))
+	}
+
+	result := h.db.Model(&models.RoutePermit{}).
+		Where("id = ? AND company_id = ? AND status = ?", permit.ID, company.ID, "active").
+		Update("fare_multiplier", multiplier)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return refuse(http.StatusNotFound, "Active permit not found")
+	}
+	permit.FareMultiplier = multiplier
+	return nil
+}
+
+// renewPermit extends a company permit by another term once renewal opens.
+// The permit's route must be loaded.
+func (h *GameHandler) renewPermit(company *models.Company, permit *models.RoutePermit) error {
+	now := simulation.Now()
+	if now.Before(permit.ExpiresAt.Add(-permitRenewalDays * simulation.GameDay)) {
+		return refuse(http.StatusBadRequest, fmt.Sprintf("Permits can be renewed within %d days of expiry", permitRenewalDays))
+	}
+
+	if company.Reputation < permit.Route.MinReputation {
+		return refuse(http.StatusBadRequest, "Reputation too low to renew this permit")
+	}
+
+	price := permitPrice(permit.Route)
+
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		permit.Price = price
+		permit.ExpiresAt = permit.ExpiresAt.Add(permitTermDays * simulation.GameDay)
+		if err := tx.Model(permit).Updates(map[string]interface{}{
+			"price":      permit.Price,
+			"expires_at": permit.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		err := spendFunds(tx, company, "expense", "Route permit renewal: "+permit.Route.Name, price)
+		return refusePayment(err, "Insufficient funds")
+	})
+}
+
+func (h *GameHandler) RevokePermit(c *gin.Context) {
+	var req RevokePermitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var permit models.RoutePermit
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "active").First(&permit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active permit not found"})
+		return
+	}
+
+	if err := revokePermit(h.db, &permit, req.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke permit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permit)
+}
+
+// processPermits expires lapsed permits, revokes permits with too many service
+// violations and hands freed slots to the highest open bids.
+func (w *World) processPermits(now time.Time) {
+	if err := w.db.Model(&models.RoutePermit{}).
+		Where("status = ? AND expires_at <= ?", "active", now).
+		Update("status", "expired").Error; err != nil {
+		log.Printf("Failed to expire permits: %v", err)
+	}
+
+	var violators []models.RoutePermit
+	w.db.Where("status = ? AND violations >= ?", "active", maxPermitViolations).Find(&violators)
+	for i := range violators {
+		if err := revokePermit(w.db, &violators[i], "Poor service"); err != nil {
+			log.Printf("Failed to revoke permit %d: %v", violators[i].ID, err)
+		}
+	}
+
+	var routeIDs []uint
+	w.db.Model(&models.PermitBid{}).Where("status = ?", "open").Distinct().Pluck("route_id", &routeIDs)
+	for _, routeID := range routeIDs {
+		w.awardPermitBids(routeID)
+	}
+}
+
+// awardPermitBids hands the route's free permit slots to its highest open
+// bids, one slot at a time under a lock on the route.
+func (w *World) awardPermitBids(routeID uint) {
+	for {
+		var route models.Route
+		var bid models.PermitBid
+		err := w.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&route, routeID).Error; err != nil {
+				return err
+			}
+			if countActivePermits(tx, route.ID) >= int64(route.PermitSlots) {
+				return errNoPermitSlot
+			}
+			if err := tx.Where("route_id = ? AND status = ?", route.ID, "open").
+				Order("amount DESC, created_at").First(&bid).Error; err != nil {
+				return err
+			}
+
+			// The bid amount was escrowed when placed, so no further payment is taken
+			result := tx.Model(&models.PermitBid{}).Where("id = ? AND status = ?", bid.ID, "open").Update("status", "won")
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return errBidNotOpen
+			}
+			_, err := grantPermit(tx, bid.CompanyID, route, bid.Amount)
+			return err
+		})
+		switch {
+		case errors.Is(err, errBidNotOpen):
+			// Withdrawn meanwhile; the next bid gets the slot
+			continue
+		case errors.Is(err, errNoPermitSlot), errors.Is(err, gorm.ErrRecordNotFound):
+			return
+		case err != nil:
+			log.Printf("Failed to award permit bid %d: %v", bid.ID, err)
+			return
+		}
+		log.Printf("Awarded permit for %s to company %d", route.Name, bid.CompanyID)
+	}
+}
+
+func grantPermit(tx *gorm.DB, companyID uint, route models.Route, price float64) (models.RoutePermit, error) {
+	now := simulation.Now()
+	permit := models.RoutePermit{
+		CompanyID: companyID,
+		RouteID:   route.ID,
+		Status:    "active",
+		Price:     price,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(permitTermDays * simulation.GameDay),
+	}
+	err := tx.Create(&permit).Error
+	return permit, err
+}
+
+func revokePermit(db *gorm.DB, permit *models.RoutePermit, reason string) error {
+	now := simulation.Now()
+	permit.Status = "revoked"
+	permit.RevokedAt = &now
+	permit.RevokedReason = reason
+	return db.Model(permit).Updates(map[string]interface{}{
+		"status":         permit.Status,
+		"revoked_at":     permit.RevokedAt,
+		"revoked_reason": permit.RevokedReason,
+	}).Error
+}
+
+// activePermit returns the company's valid permit for a route, if any.
+func activePermit(db *gorm.DB, companyID, routeID uint) (models.RoutePermit, bool) {
+	var permit models.RoutePermit
+	err := db.Where("company_id = ? AND route_id = ? AND status = ? AND expires_at > ?",
+		companyID, routeID, "active", simulation.Now()).First(&permit).Error
+	return permit, err == nil
+}
+
+func countActivePermits(db *gorm.DB, routeID uint) int64 {
+	var count int64
+	db.Model(&models.RoutePermit{}).Where("route_id = ? AND status = ?", routeID, "active").Count(&count)
+	return count
+}
+
+func permitPrice(route models.Route) float64 {
+	if route.PermitPrice > 0 {
+		return route.PermitPrice
+	}
+	return route.BaseFare * permitPriceFareRatio
+}
+
+// permitEligibilityError explains why a company may not acquire a permit for
+// route, or returns an empty string when it may.
+func permitEligibilityError(db *gorm.DB, company models.Company, route models.Route) string {
+	if company.Reputation < route.MinReputation {
+		return fmt.Sprintf("This route requires a reputation of at least %d", route.MinReputation)
+	}
+	if _, ok := activePermit(db, company.ID, route.ID); ok {
+		return "You already hold an active permit for this route"
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"bus-manager/data"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	progressionOnce sync.Once
+	progression     *simulation.Progression
+)
+
+// gameProgression returns the level curve, read from PROGRESSION_FILE when set
+// and from the bundled configuration otherwise.
+func gameProgression() *simulation.Progression {
+	progressionOnce.Do(func() {
+		if path := os.Getenv("PROGRESSION_FILE"); path != "" {
+			raw, err := os.ReadFile(path)
+			if err == nil {
+				progression, err = simulation.ParseProgression(raw)
+			}
+			if err == nil {
+				return
+			}
+			log.Printf("Failed to load progression from %s, using defaults: %v", path, err)
+		}
+
+		var err error
+		progression, err = simulation.ParseProgression(data.Progression)
+		if err != nil {
+			log.Fatalf("Invalid bundled progression: %v", err)
+		}
+	})
+	return progression
+}
+
+func (h *GameHandler) GetProgression(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	p := gameProgression()
+	current := p.Unlocks(company.Level)
+	response := gin.H{
+		"level":      company.Level,
+		"experience": company.Experience,
+		"level_xp":   current.XP,
+		"unlocks":    current,
+		"xp_rules":   p.XP,
+	}
+	if next, ok := p.Next(company.Level); ok {
+		response["next_level"] = next
+		response["next_level_xp"] = next.XP
+		response["progress"] = float64(company.Experience-current.XP) / float64(next.XP-current.XP) * 100
+	} else {
+		response["progress"] = 100.0
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// awardExperience adds xp to a company and raises its level when a threshold
+// is crossed. It returns true when the company levelled up.
+func awardExperience(db *gorm.DB, companyID uint, xp int) (models.Company, bool, error) {
+	var company models.Company
+	leveledUp := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Concurrent awards add up in the database rather than overwrite each other
+		result := tx.Model(&company).Clauses(clause.Returning{}).Where("id = ?", companyID).
+			Update("experience", gorm.Expr("experience + ?", xp))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Only the award that crosses a threshold raises the level
+		level := gameProgression().LevelFor(company.Experience).Level
+		result = tx.Model(&models.Company{}).Where("id = ? AND level < ?", companyID, level).Update("level", level)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			company.Level = level
+			leveledUp = true
+		}
+		return nil
+	})
+	return company, leveledUp, err
+}
+
+// maxDepotsForLevel is the number of depots a company may operate.
+func maxDepotsForLevel(level int) int {
+	return gameProgression().Unlocks(level).MaxDepots
+}
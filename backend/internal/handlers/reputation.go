@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDemandPopularity caps demand at twice the seats on the bus.
+const maxDemandPopularity = 200
+
+func (h *GameHandler) GetReputation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var routes []models.RouteReputation
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Route").Order("trips DESC").Find(&routes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reputation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reputation":    company.Reputation,
+		"baseline":      simulation.ReputationBaseline,
+		"demand_factor": simulation.DemandFactor(float64(company.Reputation)),
+		"routes":        routes,
+	})
+}
+
+// recordTripReputation scores a finished trip, folds it into the company's
+// reputation on the route and refreshes the overall company reputation.
+func recordTripReputation(db *gorm.DB, companyID, routeID uint, outcome simulation.TripOutcome) error {
+	scores := simulation.ScoreTrip(outcome)
+
+	reputation := routeReputation(db, companyID, routeID)
+	reputation.Score = simulation.BlendReputation(reputation.Score, scores.Overall)
+	reputation.Punctuality = simulation.BlendReputation(reputation.Punctuality, scores.Punctuality)
+	reputation.Reliability = simulation.BlendReputation(reputation.Reliability, scores.Reliability)
+	reputation.LoadFactor = simulation.BlendReputation(reputation.LoadFactor, scores.LoadFactor)
+	reputation.Condition = simulation.BlendReputation(reputation.Condition, scores.Condition)
+	reputation.Amenities = simulation.BlendReputation(reputation.Amenities, scores.Amenities)
+	reputation.Satisfaction = simulation.BlendReputation(reputation.Satisfaction, scores.Satisfaction)
+	reputation.Trips++
+	if err := db.Save(&reputation).Error; err != nil {
+		return err
+	}
+
+	return refreshCompanyReputation(db, companyID)
+}
+
+// tripOutcome summarises a completed trip for reputation scoring.
+func tripOutcome(db *gorm.DB, trip models.Trip, bus models.Bus, satisfaction float64) simulation.TripOutcome {
+	outcome := simulation.TripOutcome{
+		DelayMinutes: trip.DelayMinutes,
+		PeakLoad:     tripPeakLoad(trip),
+		Capacity:     bus.Capacity,
+		Condition:    bus.Condition,
+		Amenities:    busAmenities(db, bus.ID),
+		Satisfaction: satisfaction,
+	}
+	for _, incident := range trip.Incidents {
+		switch incident.Type {
+		case simulation.IncidentAccident:
+			outcome.Accident = true
+		case simulation.IncidentBreakdown:
+			outcome.Breakdown = true
+		}
+		outcome.Injuries += incident.Injuries
+	}
+	return outcome
+}
+
+// tripPeakLoad is the most passengers on board at once during a trip.
+func tripPeakLoad(trip models.Trip) int {
+	if len(trip.Stops) == 0 {
+		return trip.Passengers
+	}
+	peak := 0
+	for _, stop := range trip.Stops {
+		if stop.OnBoard > peak {
+			peak = stop.OnBoard
+		}
+	}
+	return peak
+}
+
+// busAmenities counts the upgrades installed on a bus.
+func busAmenities(db *gorm.DB, busID uint) int {
+	var count int64
+	db.Model(&models.BusUpgrade{}).Where("bus_id = ?", busID).Count(&count)
+	return int(count)
+}
+
+// routeReputation returns the company's reputation on a route, starting at the
+// baseline when the company has never run it.
+func routeReputation(db *gorm.DB, companyID, routeID uint) models.RouteReputation {
+	reputation := models.RouteReputation{
+		CompanyID:    companyID,
+		RouteID:      routeID,
+		Score:        simulation.ReputationBaseline,
+		Punctuality:  simulation.ReputationBaseline,
+		Reliability:  simulation.ReputationBaseline,
+		LoadFactor:   simulation.ReputationBaseline,
+		Condition:    simulation.ReputationBaseline,
+		Amenities:    simulation.ReputationBaseline,
+		Satisfaction: simulation.ReputationBaseline,
+	}
+	db.Where("company_id = ? AND route_id = ?", companyID, routeID).First(&reputation)
+	return reputation
+}
+
+// refreshCompanyReputation sets the company reputation to the trip-weighted
+// average of its route reputations.
+func refreshCompanyReputation(db *gorm.DB, companyID uint) error {
+	var routes []models.RouteReputation
+	if err := db.Where("company_id = ?", companyID).Find(&routes).Error; err != nil {
+		return err
+	}
+
+	total, weight := 0.0, 0.0
+	for _, r := range routes {
+		total += r.Score * float64(r.Trips)
+		weight += float64(r.Trips)
+	}
+	if weight == 0 {
+		return nil
+	}
+
+	return db.Model(&models.Company{}).Where("id = ?", companyID).
+		Update("reputation", int(math.Round(total/weight))).Error
+}
+
+// routeDemandPopularity adjusts a route's popularity by the operating
+// company's reputation on it, by the seasonal events and weather now, by the
+// company's green rating among the service's eco-conscious passengers, and by
+// its running marketing campaigns. Holiday rushes may push demand past the
+// seats available.
+func routeDemandPopularity(db *gorm.DB, company models.Company, route models.Route, serviceType string) int {
+	reputation := float64(company.Reputation)
+	var routeRep models.RouteReputation
+	if err := db.Where("company_id = ? AND route_id = ?", company.ID, route.ID).First(&routeRep).Error; err == nil {
+		reputation = routeRep.Score
+	}
+
+	popularity := math.Min(100, float64(route.Popularity)*simulation.DemandFactor(reputation))
+	popularity *= seasonCalendar().DemandMultiplier(simulation.Now(), route.Origin, route.Destination)
+	popularity *= simulation.WeatherAt(route.Origin, simulation.Now()).DemandFactor()
+	popularity *= simulation.GreenDemandFactor(company.GreenRating, serviceType)
+	popularity *= 1 + marketingBoost(db, company.ID, route)
+	return int(math.Min(maxDemandPopularity, math.Round(popularity)))
+}
+
+// decayReputation pulls every reputation score one game day closer to the baseline.
+func (w *World) decayReputation() {
+	var routes []models.RouteReputation
+	if err := w.db.Find(&routes).Error; err != nil {
+		log.Printf("Failed to fetch route reputations: %v", err)
+		return
+	}
+	for _, r := range routes {
+		w.db.Model(&r).Updates(map[string]interface{}{
+			"score":        simulation.DecayReputation(r.Score, 1),
+			"punctuality":  simulation.DecayReputation(r.Punctuality, 1),
+			"reliability":  simulation.DecayReputation(r.Reliability, 1),
+			"load_factor":  simulation.DecayReputation(r.LoadFactor, 1),
+			"condition":    simulation.DecayReputation(r.Condition, 1),
+			"amenities":    simulation.DecayReputation(r.Amenities, 1),
+			"satisfaction": simulation.DecayReputation(r.Satisfaction, 1),
+		})
+	}
+
+	var companies []models.Company
+	w.db.Find(&companies)
+	for _, company := range companies {
+		var count int64
+		w.db.Model(&models.RouteReputation{}).Where("company_id = ?", company.ID).Count(&count)
+		if count > 0 {
+			if err := refreshCompanyReputation(w.db, company.ID); err != nil {
+				log.Printf("Failed to refresh reputation for company %d: %v", company.ID, err)
+			}
+			continue
+		}
+		decayed := simulation.DecayReputation(float64(company.Reputation), 1)
+		w.db.Model(&company).Update("reputation", int(math.Round(decayed)))
+	}
+}
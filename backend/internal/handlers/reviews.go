@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"bus-manager/data"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultReviewLimit = 20
+	maxReviewLimit     = 100
+	defaultTrendDays   = 14
+)
+
+var (
+	reviewTemplatesOnce sync.Once
+	reviewTemplates     simulation.ReviewTemplates
+)
+
+func passengerReviewTemplates() simulation.ReviewTemplates {
+	reviewTemplatesOnce.Do(func() {
+		var err error
+		reviewTemplates, err = simulation.ParseReviewTemplates(data.Reviews)
+		if err != nil {
+			log.Fatalf("Invalid bundled review templates: %v", err)
+		}
+	})
+	return reviewTemplates
+}
+
+// SatisfactionTrend is the average satisfaction over the trips of one game day.
+type SatisfactionTrend struct {
+	Date        string  `json:"date"`
+	Trips       int     `json:"trips"`
+	Punctuality float64 `json:"punctuality"`
+	Comfort     float64 `json:"comfort"`
+	Crowding    float64 `json:"crowding"`
+	Price       float64 `json:"price"`
+	Overall     float64 `json:"overall"`
+	Rating      float64 `json:"rating"`
+}
+
+func (h *GameHandler) GetRouteReviews(c *gin.Context) {
+	h.getReviews(c, "route_id")
+}
+
+func (h *GameHandler) GetBusReviews(c *gin.Context) {
+	h.getReviews(c, "bus_id")
+}
+
+// getReviews lists the company's latest passenger reviews and daily
+// satisfaction trend for one of its routes or buses.
+func (h *GameHandler) getReviews(c *gin.Context, column string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	if column == "bus_id" {
+		var bus models.Bus
+		if err := h.db.Unscoped().Where("id = ? AND company_id = ?", c.Param("id"), company.ID).First(&bus).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+			return
+		}
+	} else {
+		var route models.Route
+		if err := h.db.First(&route, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultReviewLimit)))
+	if err != nil || limit <= 0 || limit > maxReviewLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultTrendDays)))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+		return
+	}
+
+	var reviews []models.PassengerReview
+	if err := h.db.Where("company_id = ? AND "+column+" = ?", company.ID, c.Param("id")).Order("game_time DESC, id DESC").Limit(limit).Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	since := simulation.Now().AddDate(0, 0, -days)
+	var satisfaction []models.TripSatisfaction
+	if err := h.db.Where("company_id = ? AND "+column+" = ? AND game_time >= ?", company.ID, c.Param("id"), since).
+		Order("game_time").Find(&satisfaction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch satisfaction"})
+		return
+	}
+
+	var ratings []models.PassengerReview
+	h.db.Where("company_id = ? AND "+column+" = ? AND game_time >= ?", company.ID, c.Param("id"), since).Find(&ratings)
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"trend":   satisfactionTrend(satisfaction, ratings),
+	})
+}
+
+// satisfactionTrend averages trip satisfaction and review ratings per game day.
+func satisfactionTrend(trips []models.TripSatisfaction, reviews []models.PassengerReview) []SatisfactionTrend {
+	trend := []SatisfactionTrend{}
+	index := map[string]int{}
+	for _, s := range trips {
+		date := s.GameTime.In(simulation.GameStart.Location()).Format("2006-01-02")
+		i, ok := index[date]
+		if !ok {
+			i = len(trend)
+			index[date] = i
+			trend = append(trend, SatisfactionTrend{Date: date})
+		}
+		t := &trend[i]
+		t.Trips++
+		t.Punctuality += s.Punctuality
+		t.Comfort += s.Comfort
+		t.Crowding += s.Crowding
+		t.Price += s.Price
+		t.Overall += s.Overall
+	}
+	for i := range trend {
+		n := float64(trend[i].Trips)
+		trend[i].Punctuality /= n
+		trend[i].Comfort /= n
+		trend[i].Crowding /= n
+		trend[i].Price /= n
+		trend[i].Overall /= n
+	}
+
+	ratingSums := map[string][2]int{}
+	for _, r := range reviews {
+		date := r.GameTime.In(simulation.GameStart.Location()).Format("2006-01-02")
+		sum := ratingSums[date]
+		ratingSums[date] = [2]int{sum[0] + r.Rating, sum[1] + 1}
+	}
+	for date, sum := range ratingSums {
+		if i, ok := index[date]; ok && sum[1] > 0 {
+			trend[i].Rating = float64(sum[0]) / float64(sum[1])
+		}
+	}
+	return trend
+}
+
+// recordTripSatisfaction scores how passengers felt about a completed trip and
+// stores the satisfaction with the reviews they wrote.
+func recordTripSatisfaction(db *gorm.DB, trip models.Trip, bus models.Bus) (simulation.Satisfaction, error) {
+	// Trips from before operators set their fares paid the tariff
+	fare := trip.Fare
+	if fare == 0 {
+		fare = trip.Route.BaseFare
+	}
+	farePerKm := 0.0
+	if trip.Route.Distance > 0 {
+		farePerKm = fare / trip.Route.Distance
+	}
+	s := simulation.ScoreSatisfaction(simulation.SatisfactionInput{
+		DelayMinutes:  trip.DelayMinutes,
+		BusType:       bus.Type,
+		ServiceType:   bus.ServiceType,
+		Upgrades:      busAmenities(db, bus.ID),
+		Condition:     bus.Condition,
+		PeakLoad:      tripPeakLoad(trip),
+		Capacity:      bus.Capacity,
+		FarePerKm:     farePerKm,
+		FareTolerance: seasonCalendar().FareTolerance(simulation.Now()),
+	})
+
+	// Seed by trip so a trip always gets the same reviews
+	rng := rand.New(rand.NewSource(int64(trip.ID)))
+	generated := passengerReviewTemplates().GenerateReviews(s, trip.Passengers, rng, trip.Route.Name, bus.Name, trip.DelayMinutes)
+
+	now := simulation.Now()
+	tx := db.Begin()
+
+	if err := tx.Model(&trip).Update("satisfaction", s.Overall).Error; err != nil {
+		tx.Rollback()
+		return s, err
+	}
+
+	record := models.TripSatisfaction{
+		TripID:      trip.ID,
+		CompanyID:   bus.CompanyID,
+		RouteID:     trip.RouteID,
+		BusID:       bus.ID,
+		Punctuality: s.Punctuality,
+		Comfort:     s.Comfort,
+		Crowding:    s.Crowding,
+		Price:       s.Price,
+		Overall:     s.Overall,
+		GameTime:    now,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		tx.Rollback()
+		return s, err
+	}
+
+	for _, r := range generated {
+		review := models.PassengerReview{
+			TripID:    trip.ID,
+			CompanyID: bus.CompanyID,
+			RouteID:   trip.RouteID,
+			BusID:     bus.ID,
+			Rating:    r.Rating,
+			Language:  r.Language,
+			Aspect:    r.Aspect,
+			Text:      r.Text,
+			GameTime:  now,
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			tx.Rollback()
+			return s, err
+		}
+	}
+
+	return s, tx.Commit().Error
+}
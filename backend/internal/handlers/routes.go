@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"bus-manager/internal/datapack"
+	"bus-manager/internal/geo"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	roadDistanceFactor  = 1.3     // road distance relative to straight-line distance
+	averageSpeedKmh     = 60.0    // used to derive route duration
+	fareBase            = 10000.0 // IDR
+	farePerKm           = 300.0   // IDR
+	licenseFeeBase      = 25000000.0
+	licenseFeePerKm     = 50000.0
+	highDeckerMinKm     = 500.0 // routes longer than this require a high decker
+	routeAutoApproveLvl = 3     // companies at this level skip admin approval
+	maxGeometryOffsetKm = 25.0  // allowed gap between geometry endpoints and terminals
+	minRouteDistanceKm  = 20.0
+)
+
+type CreateRouteRequest struct {
+	OriginCityID          uint   `json:"origin_city_id" binding:"required"`
+	DestinationCityID     uint   `json:"destination_city_id" binding:"required"`
+	OriginTerminalID      uint   `json:"origin_terminal_id"`
+	DestinationTerminalID uint   `json:"destination_terminal_id"`
+	Geometry              string `json:"geometry"` // optional encoded polyline of the road path
+}
+
+func (h *GameHandler) GetCities(c *gin.Context) {
+	var cities []models.City
+	if err := h.db.Preload("Terminals").Order("name").Find(&cities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cities)
+}
+
+func (h *GameHandler) CreateRoute(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req CreateRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.OriginCityID == req.DestinationCityID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Origin and destination must be different cities"})
+		return
+	}
+
+	// Resolve cities and terminals from the catalog
+	var origin, destination models.City
+	if err := h.db.Preload("Terminals").First(&origin, req.OriginCityID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Origin city not found"})
+		return
+	}
+	if err := h.db.Preload("Terminals").First(&destination, req.DestinationCityID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination city not found"})
+		return
+	}
+
+	originTerminal, ok := cityTerminal(origin, req.OriginTerminalID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Origin terminal not found in " + origin.Name})
+		return
+	}
+	destTerminal, ok := cityTerminal(destination, req.DestinationTerminalID)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination terminal not found in " + destination.Name})
+		return
+	}
+
+	name := origin.Name + " - " + destination.Name
+	var existingRoute models.Route
+	if err := h.db.Where("name = ? AND status <> ?", name, "rejected").First(&existingRoute).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Route already exists"})
+		return
+	}
+
+	// Distance from road geometry when supplied, otherwise estimated from haversine
+	from := geo.Point{Lat: originTerminal.Latitude, Lng: originTerminal.Longitude}
+	to := geo.Point{Lat: destTerminal.Latitude, Lng: destTerminal.Longitude}
+	distance := geo.Haversine(from, to) * roadDistanceFactor
+	if req.Geometry != "" {
+		points, err := geo.DecodePolyline(req.Geometry)
+		if err != nil || len(points) < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route geometry"})
+			return
+		}
+		if geo.Haversine(points[0], from) > maxGeometryOffsetKm || geo.Haversine(points[len(points)-1], to) > maxGeometryOffsetKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Route geometry does not connect the selected terminals"})
+			return
+		}
+		distance = geo.NewPath(points).Length()
+	}
+	distance = math.Round(distance)
+
+	if distance < minRouteDistanceKm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Routes must be at least %.0f km long", minRouteDistanceKm)})
+		return
+	}
+
+	duration := int(math.Round(distance / averageSpeedKmh * 60))
+
+	// Routes between islands cross on the car ferry
+	var charges []models.RouteCharge
+	if origin.Island != destination.Island {
+		crossing, ok := datapack.Current().FerryBetween(origin.Island, destination.Island)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No ferry crossing between " + origin.Island + " and " + destination.Island})
+			return
+		}
+		duration += crossing.Duration
+		charges = append(charges, ferryCharge(crossing, 0))
+	}
+	baseFare := math.Round((fareBase+distance*farePerKm)/1000) * 1000
+	licenseFee := licenseFeeBase + distance*licenseFeePerKm
+
+	routeType := "intercity"
+	if origin.Province != destination.Province {
+		routeType = "interprovince"
+	}
+	if routeType == "interprovince" && !gameProgression().Unlocks(company.Level).InterprovinceRoutes {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Interprovince routes are not unlocked at your level"})
+		return
+	}
+
+	minBusType := "normal"
+	if distance > highDeckerMinKm {
+		minBusType = "high_decker"
+	}
+
+	status := "pending"
+	if company.Level >= routeAutoApproveLvl {
+		status = "approved"
+	}
+
+	route := models.Route{
+		Name:        name,
+		Origin:      origin.Name,
+		Destination: destination.Name,
+		OriginLat:   originTerminal.Latitude,
+		OriginLng:   originTerminal.Longitude,
+		DestLat:     destTerminal.Latitude,
+		DestLng:     destTerminal.Longitude,
+		Distance:    distance,
+		Duration:    duration,
+		Popularity:  50,
+		Type:        routeType,
+		MinBusType:  minBusType,
+		BaseFare:    baseFare,
+		Geometry:    req.Geometry,
+		CompanyID:   &company.ID,
+		Status:      status,
+		LicenseFee:  licenseFee,
+		Stops: []models.RouteStop{
+			{Sequence: 0, City: origin.Name, Terminal: originTerminal.Name, Latitude: originTerminal.Latitude, Longitude: originTerminal.Longitude},
+			{
+				Sequence:  1,
+				City:      destination.Name,
+				Terminal:  destTerminal.Name,
+				Latitude:  destTerminal.Latitude,
+				Longitude: destTerminal.Longitude,
+				Distance:  distance,
+				Duration:  duration,
+				Fare:      baseFare,
+			},
+		},
+		Charges: charges,
+	}
+
+	// Start transaction
+	tx := h.db.Begin()
+
+	if err := tx.Create(&route).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create route"})
+		return
+	}
+
+	if err := spendFunds(tx, &company, "expense", "Route license: "+route.Name, licenseFee); err != nil {
+		tx.Rollback()
+		respondPaymentError(c, err, "Insufficient funds for licensing fee")
+		return
+	}
+
+	// The licensing fee covers the opening company's first permit term
+	if route.Status == "approved" {
+		if _, err := grantPermit(tx, company.ID, route, 0); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create route permit"})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	if route.Status == "approved" {
+		h.publishRouteOpened(company.ID, route)
+	}
+
+	c.JSON(http.StatusCreated, route)
+}
+
+func (h *GameHandler) GetPendingRoutes(c *gin.Context) {
+	var routes []models.Route
+	if err := h.db.Where("status = ?", "pending").Preload("Stops", orderBySequence).Preload("Charges", orderBySequence).Find(&routes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch routes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, routes)
+}
+
+func (h *GameHandler) ApproveRoute(c *gin.Context) {
+	var route models.Route
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "pending").First(&route).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending route not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Only one decision is taken on a pending route
+	route.Status = "approved"
+	result := tx.Model(&models.Route{}).Where("id = ? AND status = ?", route.ID, "pending").Update("status", route.Status)
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve route"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending route not found"})
+		return
+	}
+
+	// The licensing fee covers the opening company's first permit term
+	if route.CompanyID != nil {
+		if _, err := grantPermit(tx, *route.CompanyID, route, 0); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create route permit"})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	if route.CompanyID != nil {
+		h.publishRouteOpened(*route.CompanyID, route)
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// publishRouteOpened reports a newly approved route to the opening company's achievements.
+func (h *GameHandler) publishRouteOpened(companyID uint, route models.Route) {
+	h.hub.PublishEvent(companyID, simulation.GameEvent{
+		Type:   simulation.EventRouteOpened,
+		Values: map[string]float64{"distance": route.Distance},
+	})
+}
+
+func (h *GameHandler) RejectRoute(c *gin.Context) {
+	var route models.Route
+	if err := h.db.Where("id = ? AND status = ?", c.Param("id"), "pending").First(&route).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending route not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Only one decision is taken on a pending route
+	route.Status = "rejected"
+	result := tx.Model(&models.Route{}).Where("id = ? AND status = ?", route.ID, "pending").Update("status", route.Status)
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject route"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending route not found"})
+		return
+	}
+
+	// Refund the licensing fee to the company that applied
+	if route.CompanyID != nil && route.LicenseFee > 0 {
+		var company models.Company
+		if err := tx.First(&company, *route.CompanyID).Error; err == nil {
+			if err := recordTransaction(tx, &company, "income", "Route license refund: "+route.Name, route.LicenseFee); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund licensing fee"})
+				return
+			}
+		}
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, route)
+}
+
+// cityTerminal returns the requested terminal of a city, or its first terminal
+// when none was requested. Cities without terminals use the city centre.
+func cityTerminal(city models.City, terminalID uint) (models.Terminal, bool) {
+	if terminalID == 0 {
+		if len(city.Terminals) == 0 {
+			return models.Terminal{CityID: city.ID, Name: city.Name, Latitude: city.Latitude, Longitude: city.Longitude}, true
+		}
+		return city.Terminals[0], true
+	}
+	for _, terminal := range city.Terminals {
+		if terminal.ID == terminalID {
+			return terminal, true
+		}
+	}
+	return models.Terminal{}, false
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"bus-manager/data"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSeasonHorizonDays = 60
+
+var (
+	seasonsOnce sync.Once
+	seasons     *simulation.SeasonCalendar
+)
+
+// seasonCalendar returns the seasonal demand events, read from SEASONS_FILE
+// when set and from the bundled calendar otherwise.
+func seasonCalendar() *simulation.SeasonCalendar {
+	seasonsOnce.Do(func() {
+		if path := os.Getenv("SEASONS_FILE"); path != "" {
+			raw, err := os.ReadFile(path)
+			if err == nil {
+				seasons, err = simulation.ParseSeasonCalendar(raw)
+			}
+			if err == nil {
+				return
+			}
+			log.Printf("Failed to load seasons from %s, using defaults: %v", path, err)
+		}
+
+		var err error
+		seasons, err = simulation.ParseSeasonCalendar(data.Seasons)
+		if err != nil {
+			log.Fatalf("Invalid bundled season calendar: %v", err)
+		}
+	})
+	return seasons
+}
+
+func (h *GameHandler) GetSeasons(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultSeasonHorizonDays)))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a non-negative number"})
+		return
+	}
+
+	now := simulation.Now()
+	c.JSON(http.StatusOK, gin.H{
+		"game_time":      now,
+		"fare_tolerance": seasonCalendar().FareTolerance(now),
+		"events":         seasonCalendar().Occurrences(now, time.Duration(days)*simulation.GameDay),
+	})
+}
+
+// announceSeasons tells players about seasonal events entering their
+// announcement window, starting and ending. It runs at game midnight.
+func (w *World) announceSeasons(now time.Time) {
+	calendar := seasonCalendar()
+
+	for _, o := range calendar.Occurrences(now, 366*simulation.GameDay) {
+		switch {
+		case o.StartsAt.Equal(now):
+			w.hub.broadcast <- WSMessage{Type: "season_started", Data: o}
+		case o.AnnounceAt.Equal(now):
+			w.hub.broadcast <- WSMessage{Type: "season_announced", Data: o}
+		}
+	}
+
+	// Events that ended at midnight are no longer returned for now
+	for _, o := range calendar.Active(now.Add(-time.Minute)) {
+		if o.EndsAt.Equal(now) {
+			w.hub.broadcast <- WSMessage{Type: "season_ended", Data: o}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateTimetableRequest struct {
+	RouteID       uint  `json:"route_id" binding:"required"`
+	DepartureHour int   `json:"departure_hour" binding:"min=0,max=23"` // game hour, WIB
+	BusID         *uint `json:"bus_id"`                                // defaults to the company's first idle bus
+	AvoidTolls    bool  `json:"avoid_tolls"`
+}
+
+func (h *GameHandler) GetTimetables(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var timetables []models.Timetable
+	if err := h.db.Where("company_id = ?", company.ID).Preload("Route").Preload("Bus").
+		Order("route_id, departure_hour").Find(&timetables).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timetables"})
+		return
+	}
+
+	c.JSON(http.StatusOK, timetables)
+}
+
+func (h *GameHandler) CreateTimetable(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var req CreateTimetableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	timetable, err := h.openTimetable(&company, req)
+	if err != nil {
+		respondActionError(c, err, "Failed to open timetable")
+		return
+	}
+
+	c.JSON(http.StatusCreated, timetable)
+}
+
+func (h *GameHandler) DeleteTimetable(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND company_id = ?", c.Param("id"), company.ID).Delete(&models.Timetable{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close timetable"})
+		return
+	}
+	if result.RowsAffected != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Timetable not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Timetable closed"})
+}
+
+// openTimetable schedules a daily departure on a route the company holds a
+// permit for.
+func (h *GameHandler) openTimetable(company *models.Company, req CreateTimetableRequest) (models.Timetable, error) {
+	if req.DepartureHour < 0 || req.DepartureHour > 23 {
+		return models.Timetable{}, refuse(http.StatusBadRequest, "Departure hour must be between 0 and 23")
+	}
+
+	var route models.Route
+	if err := h.db.Where("status = ?", "approved").First(&route, req.RouteID).Error; err != nil {
+		return models.Timetable{}, refuse(http.StatusBadRequest, "Route not found")
+	}
+	if _, ok := activePermit(h.db, company.ID, route.ID); !ok {
+		return models.Timetable{}, refuse(http.StatusForbidden, "No active route permit for this route")
+	}
+
+	if req.BusID != nil {
+		var bus models.Bus
+		if err := h.db.Where("id = ? AND company_id = ?", *req.BusID, company.ID).First(&bus).Error; err != nil {
+			return models.Timetable{}, refuse(http.StatusBadRequest, "Bus not found or not owned by company")
+		}
+	}
+
+	var count int64
+	h.db.Model(&models.Timetable{}).
+		Where("company_id = ? AND route_id = ? AND departure_hour = ?", company.ID, route.ID, req.DepartureHour).Count(&count)
+	if count > 0 {
+		return models.Timetable{}, refuse(http.StatusConflict, fmt.Sprintf("A %02d:00 departure is already scheduled on this route", req.DepartureHour))
+	}
+
+	timetable := models.Timetable{
+		CompanyID:     company.ID,
+		RouteID:       route.ID,
+		DepartureHour: req.DepartureHour,
+		BusID:         req.BusID,
+		AvoidTolls:    req.AvoidTolls,
+		Route:         route,
+	}
+	if err := h.db.Create(&timetable).Error; err != nil {
+		return models.Timetable{}, err
+	}
+	return timetable, nil
+}
+
+// processTimetables runs the departures scheduled for this game hour through
+// the same dispatch as the players' trips.
+func (w *World) processTimetables(now time.Time) {
+	var timetables []models.Timetable
+	if err := w.db.Where("departure_hour = ?", now.Hour()).Order("id").Find(&timetables).Error; err != nil {
+		log.Printf("Failed to fetch timetabled departures: %v", err)
+		return
+	}
+
+	for _, timetable := range timetables {
+		updates := map[string]interface{}{"last_departure_at": now, "last_trip_id": nil, "last_error": ""}
+		trip, err := w.game.runDeparture(timetable)
+		var refused *actionError
+		switch {
+		case errors.As(err, &refused):
+			updates["last_error"] = refused.message
+		case err != nil:
+			log.Printf("Failed to run timetable %d: %v", timetable.ID, err)
+			updates["last_error"] = "Dispatch failed"
+		default:
+			updates["last_trip_id"] = trip.ID
+		}
+		w.db.Model(&timetable).Updates(updates)
+	}
+}
+
+// runDeparture dispatches the timetable's bus, or the company's first idle bus
+// not kept for other departures.
+func (h *GameHandler) runDeparture(timetable models.Timetable) (models.Trip, error) {
+	var company models.Company
+	if err := h.db.First(&company, timetable.CompanyID).Error; err != nil {
+		return models.Trip{}, err
+	}
+
+	busID := timetable.BusID
+	if busID == nil {
+		var bus models.Bus
+		err := h.db.Where("company_id = ? AND status = ?", company.ID, "available").
+			Where("id NOT IN (SELECT bus_id FROM timetables WHERE company_id = ? AND bus_id IS NOT NULL)", company.ID).
+			Order("id").First(&bus).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Trip{}, refuse(http.StatusConflict, "No idle bus")
+		} else if err != nil {
+			return models.Trip{}, err
+		}
+		busID = &bus.ID
+	}
+
+	return h.dispatchTrip(&company, CreateTripRequest{
+		BusID:      *busID,
+		RouteID:    timetable.RouteID,
+		AvoidTolls: timetable.AvoidTolls,
+	})
+}
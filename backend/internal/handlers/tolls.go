@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"bus-manager/internal/datapack"
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RouteChargeQuote is what a route's tolls and ferries cost a bus, with and
+// without the non-toll alternatives.
+type RouteChargeQuote struct {
+	BusClass      string              `json:"bus_class"`
+	Charges       []models.TripCharge `json:"charges"`
+	Total         float64             `json:"total"` // IDR
+	AvoidTolls    []models.TripCharge `json:"avoid_tolls"`
+	AvoidTotal    float64             `json:"avoid_total"` // IDR, fees only
+	DetourKm      float64             `json:"detour_km"`
+	DetourMinutes int                 `json:"detour_minutes"`
+	DetourCost    float64             `json:"detour_cost"` // IDR of operating cost for the extra km
+}
+
+func (h *GameHandler) GetRouteCharges(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Get user's company
+	var company models.Company
+	if err := h.db.Where("user_id = ?", userID).First(&company).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+		return
+	}
+
+	var route models.Route
+	if err := h.db.Preload("Charges", orderBySequence).First(&route, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+
+	// Quote every class, or only the class of the given bus
+	buses := []models.Bus{{Capacity: 30}, {Capacity: 50}, {Capacity: 60}}
+	if busID := c.Query("bus_id"); busID != "" {
+		var bus models.Bus
+		if err := h.db.Where("id = ? AND company_id = ?", busID, company.ID).First(&bus).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bus not found or not owned by company"})
+			return
+		}
+		buses = []models.Bus{bus}
+	}
+
+	quotes := make([]RouteChargeQuote, 0, len(buses))
+	for _, bus := range buses {
+		charges, _, _ := tripCharges(route, bus, false)
+		avoid, detourKm, detourMinutes := tripCharges(route, bus, true)
+		quotes = append(quotes, RouteChargeQuote{
+			BusClass:      simulation.BusClass(bus.Capacity),
+			Charges:       charges,
+			Total:         chargesTotal(charges),
+			AvoidTolls:    avoid,
+			AvoidTotal:    chargesTotal(avoid),
+			DetourKm:      detourKm,
+			DetourMinutes: detourMinutes,
+			DetourCost:    detourKm * bus.OperatingCost,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"route_id": route.ID,
+		"sections": route.Charges,
+		"quotes":   quotes,
+	})
+}
+
+// tripCharges lists the fees a bus pays on a route. Avoiding tolls skips the
+// sections that have a non-toll alternative and returns the extra distance and
+// time the detours take.
+func tripCharges(route models.Route, bus models.Bus, avoidTolls bool) ([]models.TripCharge, float64, int) {
+	class := simulation.BusClass(bus.Capacity)
+	charges := make([]models.TripCharge, 0, len(route.Charges))
+	detourKm, detourMinutes := 0.0, 0
+	for _, charge := range route.Charges {
+		if avoidTolls && charge.Kind == simulation.ChargeToll && charge.AltDuration > 0 {
+			detourKm += charge.AltDistance
+			detourMinutes += charge.AltDuration
+			continue
+		}
+		fees := simulation.ClassFees{Small: charge.FeeSmall, Medium: charge.FeeMedium, Large: charge.FeeLarge}
+		charges = append(charges, models.TripCharge{
+			Kind:     charge.Kind,
+			Name:     charge.Name,
+			BusClass: class,
+			Amount:   fees.For(class),
+		})
+	}
+	return charges, detourKm, detourMinutes
+}
+
+func chargesTotal(charges []models.TripCharge) float64 {
+	total := 0.0
+	for _, charge := range charges {
+		total += charge.Amount
+	}
+	return total
+}
+
+// payTripCharges debits every toll and ferry fee of a trip as its own ledger entry.
+func payTripCharges(tx *gorm.DB, company *models.Company, route models.Route, charges []models.TripCharge) error {
+	for _, charge := range charges {
+		description := "Toll: " + charge.Name + " (" + route.Name + ")"
+		if charge.Kind == simulation.ChargeFerry {
+			description = "Ferry: " + charge.Name + " (" + route.Name + ")"
+		}
+		if err := spendFunds(tx, company, charge.Kind, description, charge.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ferryCharge is the route charge for a ferry crossing.
+func ferryCharge(crossing datapack.Ferry, sequence int) models.RouteCharge {
+	return models.RouteCharge{
+		Sequence:  sequence,
+		Kind:      simulation.ChargeFerry,
+		Name:      crossing.Name,
+		FeeSmall:  crossing.Fees.Small,
+		FeeMedium: crossing.Fees.Medium,
+		FeeLarge:  crossing.Fees.Large,
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultWeatherForecastDays = 3
+	maxWeatherForecastDays     = 14
+)
+
+// CityWeather is the weather of a city today and the days ahead.
+type CityWeather struct {
+	City      string               `json:"city"`
+	Latitude  float64              `json:"latitude"`
+	Longitude float64              `json:"longitude"`
+	Today     simulation.Weather   `json:"today"`
+	Forecast  []simulation.Weather `json:"forecast"`
+}
+
+func (h *GameHandler) GetWeather(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultWeatherForecastDays)))
+	if err != nil || days < 0 || days > maxWeatherForecastDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 0 and 14"})
+		return
+	}
+
+	// Weather is only forecast 14 days ahead of the game clock
+	day := simulation.Now()
+	horizon := time.Date(day.Year(), day.Month(), day.Day()+maxWeatherForecastDays, 0, 0, 0, 0, day.Location())
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, simulation.GameStart.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+		if parsed.After(horizon) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be within the 14-day forecast"})
+			return
+		}
+		day = parsed
+	}
+
+	query := h.db.Order("name")
+	if city := c.Query("city"); city != "" {
+		query = query.Where("name = ?", city)
+	}
+
+	var cities []models.City
+	if err := query.Find(&cities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cities"})
+		return
+	}
+
+	reports := make([]CityWeather, 0, len(cities))
+	for _, city := range cities {
+		report := CityWeather{
+			City:      city.Name,
+			Latitude:  city.Latitude,
+			Longitude: city.Longitude,
+			Today:     simulation.WeatherAt(city.Name, day),
+			Forecast:  make([]simulation.Weather, 0, days),
+		}
+		for d := 1; d <= days && !day.AddDate(0, 0, d).After(horizon); d++ {
+			report.Forecast = append(report.Forecast, simulation.WeatherAt(city.Name, day.AddDate(0, 0, d)))
+		}
+		reports = append(reports, report)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_time": simulation.Now(),
+		"cities":    reports,
+	})
+}
+
+// tripWeather is the weather at every stop of a trip's route today.
+func tripWeather(trip models.Trip) []simulation.Weather {
+	stops := routeStops(trip.Route)
+	cities := make([]string, len(stops))
+	for i, stop := range stops {
+		cities[i] = stop.City
+	}
+	return simulation.RouteWeather(cities, simulation.Now())
+}
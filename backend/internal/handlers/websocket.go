@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"bus-manager/internal/geo"
 	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -13,6 +21,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// WebSocket tickets are single-use and expire quickly, so they are harmless
+// once they show up in access logs.
+const wsTicketTTL = 30 * time.Second
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for development
@@ -20,10 +32,12 @@ var upgrader = websocket.Upgrader{
 }
 
 type WSMessage struct {
-	Type   string      `json:"type"`
-	Data   interface{} `json:"data"`
-	TripID uint        `json:"trip_id,omitempty"`
-	BusID  uint        `json:"bus_id,omitempty"`
+	Type    string      `json:"type"`
+	Data    interface{} `json:"data"`
+	TripID  uint        `json:"trip_id,omitempty"`
+	BusID   uint        `json:"bus_id,omitempty"`
+	Weather interface{} `json:"weather,omitempty"` // conditions where the bus is
+	UserID  uint        `json:"-"`                 // deliver only to this user's connections when set
 }
 
 type WSClient struct {
@@ -69,6 +83,9 @@ func (h *WSHub) Run() {
 
 		case message := <-h.broadcast:
 			for client := range h.clients {
+				if message.UserID != 0 && client.userID != message.UserID {
+					continue
+				}
 				select {
 				case client.send <- message:
 				default:
@@ -140,13 +157,51 @@ func (c *WSClient) subscribeToTrip(tripID uint) {
 	}
 }
 
-func HandleWebSocket(c *gin.Context) {
-	// This is a placeholder - in a real implementation, you would:
-	// 1. Authenticate the WebSocket connection
-	// 2. Get the user ID from the JWT token
-	// 3. Create a new client and register it with the hub
+// CreateWSTicket issues a one-time ticket that authenticates a WebSocket
+// connection, so the access token never has to travel in a URL.
+func (h *GameHandler) CreateWSTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+	ticket := hex.EncodeToString(raw)
+	if err := h.rdb.Set(context.Background(), "ws_ticket:"+ticket, userID, wsTicketTTL).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(wsTicketTTL.Seconds()),
+	})
+}
+
+func (h *WSHub) HandleWebSocket(c *gin.Context) {
+	// Browsers cannot set headers on WebSocket requests, so clients pass a
+	// one-time ticket from CreateWSTicket in the query string. Anonymous
+	// clients only get public updates.
+	var userID uint
+	if ticket := c.Query("ticket"); ticket != "" {
+		value, err := h.rdb.GetDel(context.Background(), "ws_ticket:"+ticket).Result()
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			return
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			return
+		}
+		userID = uint(id)
+	}
 
-	// For now, we'll create a simple echo WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -154,9 +209,12 @@ func HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &WSClient{
-		conn: conn,
-		send: make(chan WSMessage, 256),
+		hub:    h,
+		conn:   conn,
+		send:   make(chan WSMessage, 256),
+		userID: userID,
 	}
+	h.register <- client
 
 	// Start goroutines
 	go client.writePump()
@@ -177,7 +235,8 @@ func (h *WSHub) StartTripSimulation(tripID uint) {
 
 func (h *WSHub) simulateTrip(tripID uint) {
 	var trip models.Trip
-	if err := h.db.Preload("Bus").Preload("Route").First(&trip, tripID).Error; err != nil {
+	if err := h.db.Preload("Bus").Preload("Route.Stops", orderBySequence).Preload("Stops", orderBySequence).Preload("Charges").
+		First(&trip, tripID).Error; err != nil {
 		return
 	}
 
@@ -194,32 +253,82 @@ func (h *WSHub) simulateTrip(tripID uint) {
 	}
 	h.broadcast <- message
 
+	path := routePath(trip.Route)
+	stopFractions := stopProgress(routeStops(trip.Route))
+	nextStop := 0
+	weather := tripWeather(trip)
+	incidents := planIncidents(trip, weather)
+	nextIncident := 0
+	damage := 0.0
+
 	// Simulate trip progress
 	steps := 10
 	for i := 0; i <= steps; i++ {
 		progress := float64(i) / float64(steps) * 100
 		trip.Progress = progress
 
-		// Calculate current position (linear interpolation)
-		if i > 0 {
-			ratio := float64(i) / float64(steps)
-			trip.CurrentLat = trip.Route.OriginLat + (trip.Route.DestLat-trip.Route.OriginLat)*ratio
-			trip.CurrentLng = trip.Route.OriginLng + (trip.Route.DestLng-trip.Route.OriginLng)*ratio
-		}
+		// Calculate current position along the road path by distance travelled
+		ratio := float64(i) / float64(steps)
+		position := path.PointAt(ratio)
+		trip.CurrentLat = position.Lat
+		trip.CurrentLng = position.Lng
 
 		// Update database
 		h.db.Save(&trip)
 
+		// Report every stop the bus has reached since the last step
+		for nextStop < len(trip.Stops) && nextStop < len(stopFractions) && stopFractions[nextStop] <= ratio {
+			h.arriveAtStop(&trip, nextStop)
+			nextStop++
+		}
+
+		// Rain on the way to the next stop slows the bus down
+		conditions := weather[min(nextStop, len(weather)-1)]
+		stepMinutes := (trip.Route.Duration + trip.DetourMinutes) / steps
+		delay := 0
+		if i < steps {
+			delay = int(math.Round(float64(stepMinutes) * (conditions.SpeedFactor() - 1)))
+		}
+		if delay > 0 {
+			trip.DelayMinutes += delay
+			h.db.Model(&trip).Update("delay_minutes", trip.DelayMinutes)
+		}
+
+		// Incidents on the road since the last step hold the bus up
+		for nextIncident < len(incidents) && incidents[nextIncident].At <= ratio {
+			generated := incidents[nextIncident]
+			nextIncident++
+
+			incident, err := recordIncident(h.db, trip, generated, path.PointAt(generated.At))
+			if err != nil {
+				log.Printf("Failed to record incident on trip %d: %v", tripID, err)
+				continue
+			}
+			trip.Incidents = append(trip.Incidents, incident)
+			trip.DelayMinutes += incident.DelayMinutes
+			delay += incident.DelayMinutes
+			damage += incident.Damage
+			h.db.Model(&trip).Update("delay_minutes", trip.DelayMinutes)
+
+			h.broadcast <- WSMessage{
+				Type:   "trip_incident",
+				Data:   incident,
+				TripID: tripID,
+				BusID:  trip.BusID,
+			}
+		}
+
 		// Broadcast update
 		message := WSMessage{
-			Type:   "trip_progress",
-			Data:   trip,
-			TripID: tripID,
+			Type:    "trip_progress",
+			Data:    trip,
+			TripID:  tripID,
+			Weather: conditions,
 		}
 		h.broadcast <- message
 
-		// Sleep for simulation (trip duration / steps)
-		time.Sleep(time.Duration(trip.Route.Duration/steps) * time.Second)
+		// Sleep for simulation (trip duration / steps, plus any delay)
+		time.Sleep(time.Duration(stepMinutes+delay) * time.Second)
 	}
 
 	// Complete trip
@@ -230,14 +339,78 @@ func (h *WSHub) simulateTrip(tripID uint) {
 	trip.CurrentLng = trip.Route.DestLng
 	h.db.Save(&trip)
 
-	// Update bus status
+	// Update bus status. Passengers rate the bus as it arrived, so the
+	// reloaded bus stands in for the one loaded at departure.
 	var bus models.Bus
-	if err := h.db.First(&bus, trip.BusID).Error; err == nil {
-		bus.Status = "available"
-		bus.CurrentFuel -= trip.Route.Distance / 10 // Consume fuel
-		h.db.Save(&bus)
+	if err := h.db.Preload("Depot").First(&bus, trip.BusID).Error; err != nil {
+		log.Printf("Failed to load bus %d at the end of trip %d: %v", trip.BusID, tripID, err)
+		bus = trip.Bus
+	} else {
+		returnToDepot(h.db, &bus, trip.EnergyUsed, trip.Route.Distance+trip.DetourKm, damage)
+	}
+	if err := logAdvertisingKm(h.db, trip.BusID, trip.Route.Distance+trip.DetourKm); err != nil {
+		log.Printf("Failed to log advertising distance for trip %d: %v", tripID, err)
 	}
 
+	// Fares, parcel fees and the running costs are settled on arrival; tolls
+	// and ferries were paid on departure
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var company models.Company
+		if err := tx.First(&company, trip.CompanyID).Error; err != nil {
+			return err
+		}
+		if trip.Revenue > 0 {
+			description := fmt.Sprintf("Fares: %s (%d passengers)", trip.Route.Name, trip.Passengers)
+			if err := recordTransaction(tx, &company, "fare", description, trip.Revenue); err != nil {
+				return err
+			}
+		}
+		if trip.CargoRevenue > 0 {
+			description := fmt.Sprintf("Parcel delivery: %s (%.0f kg)", trip.Route.Name, trip.CargoKg)
+			if err := recordTransaction(tx, &company, "cargo", description, trip.CargoRevenue); err != nil {
+				return err
+			}
+		}
+		if operating := trip.Cost - chargesTotal(trip.Charges); operating > 0 {
+			description := fmt.Sprintf("Operating cost: %s (%.0f km)", trip.Route.Name, trip.Route.Distance+trip.DetourKm)
+			return recordTransaction(tx, &company, "operating", description, -operating)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to settle trip %d: %v", tripID, err)
+	}
+
+	// Award experience to the operating company
+	xp := gameProgression().TripExperience(trip.Passengers, trip.Route.Distance, trip.DelayMinutes)
+	h.awardExperience(trip.CompanyID, xp)
+
+	// Passengers rate the trip, which feeds the company's reputation on the route
+	satisfaction, err := recordTripSatisfaction(h.db, trip, bus)
+	if err != nil {
+		log.Printf("Failed to record satisfaction for trip %d: %v", tripID, err)
+	}
+	trip.Satisfaction = satisfaction.Overall
+	if err := recordTripReputation(h.db, trip.CompanyID, trip.RouteID, tripOutcome(h.db, trip, bus, satisfaction.Overall)); err != nil {
+		log.Printf("Failed to record reputation for trip %d: %v", tripID, err)
+	}
+
+	onTime := 0.0
+	if trip.DelayMinutes <= gameProgression().XP.OnTimeToleranceMinutes {
+		onTime = 1
+	}
+	h.PublishEvent(trip.CompanyID, simulation.GameEvent{
+		Type: simulation.EventTripCompleted,
+		Values: map[string]float64{
+			"passengers":    float64(trip.Passengers),
+			"distance":      trip.Route.Distance,
+			"revenue":       trip.Revenue,
+			"delay_minutes": float64(trip.DelayMinutes),
+			"on_time":       onTime,
+			"satisfaction":  trip.Satisfaction,
+		},
+	})
+
 	// Broadcast completion
 	completionMessage := WSMessage{
 		Type:   "trip_completed",
@@ -246,3 +419,58 @@ func (h *WSHub) simulateTrip(tripID uint) {
 	}
 	h.broadcast <- completionMessage
 }
+
+func (h *WSHub) arriveAtStop(trip *models.Trip, index int) {
+	now := time.Now()
+	stop := &trip.Stops[index]
+	stop.Status = "arrived"
+	stop.ArrivedAt = &now
+	h.db.Save(stop)
+
+	h.broadcast <- WSMessage{
+		Type:   "trip_stop_reached",
+		Data:   stop,
+		TripID: trip.ID,
+		BusID:  trip.BusID,
+	}
+}
+
+// stopProgress returns the fraction of the route distance at which each stop is
+// reached.
+func stopProgress(stops []models.RouteStop) []float64 {
+	total := 0.0
+	for _, stop := range stops {
+		total += stop.Distance
+	}
+
+	fractions := make([]float64, len(stops))
+	travelled := 0.0
+	for i, stop := range stops {
+		travelled += stop.Distance
+		if total > 0 {
+			fractions[i] = travelled / total
+		}
+	}
+	fractions[len(fractions)-1] = 1
+	return fractions
+}
+
+// routePath returns the road geometry of a route, falling back to a straight
+// line between origin and destination when no geometry has been imported.
+func routePath(route models.Route) *geo.Path {
+	if route.Geometry != "" {
+		points, err := geo.DecodePolyline(route.Geometry)
+		switch {
+		case err != nil:
+			log.Printf("Invalid geometry for route %d, using straight line: %v", route.ID, err)
+		case len(points) < 2:
+			log.Printf("Geometry for route %d has %d point(s), using straight line", route.ID, len(points))
+		default:
+			return geo.NewPath(points)
+		}
+	}
+	return geo.NewPath([]geo.Point{
+		{Lat: route.OriginLat, Lng: route.OriginLng},
+		{Lat: route.DestLat, Lng: route.DestLng},
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"bus-manager/internal/models"
+	"bus-manager/internal/simulation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// One real minute advances the world by one game hour.
+const worldTickInterval = time.Minute
+
+// World advances the persisted game clock and runs periodic simulation jobs.
+type World struct {
+	db   *gorm.DB
+	hub  *WSHub
+	game *GameHandler // AI companies take the same game actions as players
+}
+
+func NewWorld(db *gorm.DB, rdb *redis.Client, hub *WSHub) *World {
+	return &World{
+		db:   db,
+		hub:  hub,
+		game: NewGameHandler(db, rdb, hub),
+	}
+}
+
+func (w *World) Run() {
+	w.loadClock()
+
+	ticker := time.NewTicker(worldTickInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := simulation.Advance(worldTickInterval * simulation.TimeScale)
+		w.saveClock(now)
+		w.tick(now)
+	}
+}
+
+func (w *World) tick(now time.Time) {
+	w.processPermits(now)
+	w.processLoans(now)
+	w.processInsurance(now)
+	w.processCharging(now)
+	w.processRoadClosures(now)
+	w.processCharters(now)
+	w.processAdvertising(now)
+	w.processTimetables(now)
+	w.processAICompanies(now)
+	if now.Hour() == 0 {
+		w.decayReputation()
+		w.announceSeasons(now)
+		w.refreshGreenRatings()
+		w.processContracts(now)
+		w.processMarketing(now)
+		if now.Day() == 1 {
+			w.settleEmissions()
+		}
+	}
+}
+
+func (w *World) loadClock() {
+	var state models.WorldState
+	if err := w.db.First(&state, 1).Error; err != nil {
+		state = models.WorldState{ID: 1, GameTime: simulation.GameStart}
+		if err := w.db.Create(&state).Error; err != nil {
+			log.Printf("Failed to create world state: %v", err)
+		}
+	}
+	// Postgres hands the time back in the connection's zone; the game runs on WIB
+	now := state.GameTime.In(simulation.GameStart.Location())
+	simulation.SetNow(now)
+	log.Printf("World clock at %s", now.Format(time.RFC3339))
+}
+
+func (w *World) saveClock(now time.Time) {
+	if err := w.db.Model(&models.WorldState{ID: 1}).Update("game_time", now).Error; err != nil {
+		log.Printf("Failed to save world clock: %v", err)
+	}
+}
+
+func (h *GameHandler) GetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"game_time":  simulation.Now(),
+		"time_scale": simulation.TimeScale,
+	})
+}
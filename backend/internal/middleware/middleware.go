@@ -2,13 +2,17 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"strings"
 
+	"bus-manager/internal/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 type Claims struct {
@@ -51,22 +55,9 @@ func AuthMiddleware(redisClient *redis.Client) gin.HandlerFunc {
 		}
 
 		// Parse and validate token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(getJWTSecret()), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		// Check if token is blacklisted (logged out)
-		ctx := context.Background()
-		_, err = redisClient.Get(ctx, "blacklist:"+tokenString).Result()
-		if err == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+		claims, err := ValidateToken(redisClient, tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
@@ -78,9 +69,48 @@ func AuthMiddleware(redisClient *redis.Client) gin.HandlerFunc {
 	}
 }
 
+// ValidateToken parses an access token and rejects it when it is invalid or
+// has been revoked by logging out.
+func ValidateToken(redisClient *redis.Client, tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(getJWTSecret()), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("Invalid token")
+	}
+
+	// Check if token is blacklisted (logged out)
+	if _, err := redisClient.Get(context.Background(), "blacklist:"+tokenString).Result(); err == nil {
+		return nil, errors.New("Token has been revoked")
+	}
+	return claims, nil
+}
+
 func getJWTSecret() string {
 	if secret := os.Getenv("JWT_SECRET"); secret != "" {
 		return secret
 	}
 	return "your-secret-key-change-in-production"
 }
+
+// AdminMiddleware must run after AuthMiddleware and only lets administrators through.
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, userID).Error; err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
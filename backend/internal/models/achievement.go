@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AchievementProgress tracks a company's progress toward one configured
+// achievement or quest.
+type AchievementProgress struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CompanyID     uint       `json:"company_id" gorm:"not null;uniqueIndex:idx_achievement_progress"`
+	AchievementID string     `json:"achievement_id" gorm:"not null;uniqueIndex:idx_achievement_progress"`
+	Progress      float64    `json:"progress" gorm:"default:0"`
+	Completed     bool       `json:"completed" gorm:"default:false"`
+	CompletedAt   *time.Time `json:"completed_at"` // game time
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TripParcel is the parcel cargo consigned at the route origin for one stop of
+// a trip and carried in the luggage hold.
+type TripParcel struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TripID    uint      `json:"trip_id" gorm:"not null;index"`
+	City      string    `json:"city" gorm:"not null"`
+	Terminal  string    `json:"terminal"`
+	WeightKg  float64   `json:"weight_kg" gorm:"not null"`
+	Fee       float64   `json:"fee" gorm:"not null"` // IDR, paid on delivery
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// CharterJob is a private hire (school trip, corporate outing) on the job
+// board. A company books the job, then dispatches a bus meeting its
+// requirements before the departure time; the bus is away until the job ends
+// and the client pays on its return.
+type CharterJob struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	CompanyID       *uint      `json:"company_id" gorm:"index"` // nil while on the board
+	BusID           *uint      `json:"bus_id"`
+	Status          string     `json:"status" gorm:"default:open"` // open, booked, in_progress, completed, failed, cancelled, expired
+	Kind            string     `json:"kind" gorm:"not null"`       // school_trip, corporate_event, wedding, pilgrimage
+	Client          string     `json:"client" gorm:"not null"`
+	Origin          string     `json:"origin" gorm:"not null"`
+	Destination     string     `json:"destination" gorm:"not null"`
+	Distance        float64    `json:"distance" gorm:"not null"` // km, round trip
+	Duration        int        `json:"duration" gorm:"not null"` // minutes away, driving and waiting
+	Passengers      int        `json:"passengers" gorm:"not null"`
+	ServiceType     string     `json:"service_type"`                     // required service, empty for any
+	Pay             float64    `json:"pay" gorm:"not null"`              // IDR
+	CancellationFee float64    `json:"cancellation_fee" gorm:"not null"` // IDR owed when a booked job is not run
+	StartsAt        time.Time  `json:"starts_at"`                        // game time, latest departure
+	EndsAt          *time.Time `json:"ends_at"`                          // game time, set on dispatch
+	OfferExpiresAt  time.Time  `json:"offer_expires_at"`                 // game time
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
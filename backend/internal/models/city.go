@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// City is an entry in the catalog of places routes can be opened between.
+type City struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null"`
+	Province  string    `json:"province" gorm:"not null"`
+	Island    string    `json:"island" gorm:"not null"`
+	Latitude  float64   `json:"latitude" gorm:"not null"`
+	Longitude float64   `json:"longitude" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Terminals []Terminal `json:"terminals"`
+}
+
+type Terminal struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CityID    uint      `json:"city_id" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null"`
+	Latitude  float64   `json:"latitude" gorm:"not null"`
+	Longitude float64   `json:"longitude" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
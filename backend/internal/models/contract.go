@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SubsidyContract is a government contract paying a company to run a set
+// number of daily departures on a remote (pioneer) route. Open contracts are
+// offers any company may accept until they expire.
+type SubsidyContract struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	RouteID             uint       `json:"route_id" gorm:"not null;index"`
+	CompanyID           *uint      `json:"company_id" gorm:"index"`    // nil while open
+	Status              string     `json:"status" gorm:"default:open"` // open, active, completed, terminated, cancelled, expired
+	DailyDepartures     int        `json:"daily_departures" gorm:"not null"`
+	Days                int        `json:"days" gorm:"not null"`
+	PaymentPerDeparture float64    `json:"payment_per_departure" gorm:"not null"` // IDR
+	PenaltyPerMissed    float64    `json:"penalty_per_missed" gorm:"not null"`    // IDR
+	OfferExpiresAt      time.Time  `json:"offer_expires_at"`                      // game time
+	StartsAt            *time.Time `json:"starts_at"`                             // game time, the midnight after acceptance
+	EndsAt              *time.Time `json:"ends_at"`                               // game time
+	DayDepartures       int        `json:"day_departures" gorm:"default:0"`       // departures so far today
+	DaysServed          int        `json:"days_served" gorm:"default:0"`
+	Departures          int        `json:"departures" gorm:"default:0"` // counted towards the contract
+	Missed              int        `json:"missed" gorm:"default:0"`
+	Paid                float64    `json:"paid" gorm:"default:0"`
+	Penalties           float64    `json:"penalties" gorm:"default:0"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	// Relations
+	Route Route `json:"route" gorm:"foreignKey:RouteID"`
+}
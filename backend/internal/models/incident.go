@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Incident is something that happened to a bus during a trip.
+type Incident struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	TripID       uint       `json:"trip_id" gorm:"not null;index"`
+	CompanyID    uint       `json:"company_id" gorm:"not null;index"`
+	RouteID      uint       `json:"route_id" gorm:"not null;index"`
+	BusID        uint       `json:"bus_id" gorm:"not null"`
+	Type         string     `json:"type" gorm:"not null"` // traffic_jam, flood, landslide, accident, breakdown
+	Severity     int        `json:"severity"`             // 1 minor - 3 severe
+	Progress     float64    `json:"progress"`             // percentage of the route 0-100
+	Latitude     float64    `json:"latitude"`
+	Longitude    float64    `json:"longitude"`
+	DelayMinutes int        `json:"delay_minutes"`
+	Cost         float64    `json:"cost"`         // IDR
+	Compensation float64    `json:"compensation"` // IDR paid to injured passengers, part of the cost
+	Damage       float64    `json:"damage"`       // bus condition lost
+	Injuries     int        `json:"injuries"`
+	RoadClosed   bool       `json:"road_closed"`  // the route is closed until ClosedUntil
+	ClosedUntil  *time.Time `json:"closed_until"` // game time the road reopens
+	GameTime     time.Time  `json:"game_time"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// InsuranceClaim is a claim for the part of an incident's cost one coverage pays for.
+type InsuranceClaim struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	IncidentID uint      `json:"incident_id" gorm:"not null;uniqueIndex:idx_incident_coverage"`
+	Coverage   string    `json:"coverage" gorm:"not null;uniqueIndex:idx_incident_coverage"` // collision, towing, liability
+	CompanyID  uint      `json:"company_id" gorm:"not null;index"`
+	PolicyID   *uint     `json:"policy_id"`              // nil when the bus was not insured
+	Amount     float64   `json:"amount" gorm:"not null"` // IDR claimed
+	Deductible float64   `json:"deductible" gorm:"default:0"`
+	Payout     float64   `json:"payout" gorm:"default:0"`
+	Status     string    `json:"status" gorm:"default:uninsured"` // uninsured, paid, rejected
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Relations
+	Incident Incident `json:"incident" gorm:"foreignKey:IncidentID"`
+}
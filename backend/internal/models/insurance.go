@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// InsurancePolicy insures one bus for one coverage, with the premium billed
+// weekly on the game clock.
+type InsurancePolicy struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CompanyID     uint       `json:"company_id" gorm:"not null;index"`
+	BusID         uint       `json:"bus_id" gorm:"not null;index;uniqueIndex:idx_active_policy,where:status = 'active'"`
+	Coverage      string     `json:"coverage" gorm:"not null;uniqueIndex:idx_active_policy,where:status = 'active'"` // collision, towing, liability
+	Premium       float64    `json:"premium" gorm:"not null"`                                                        // IDR per week, adjusted at every billing
+	Deductible    float64    `json:"deductible" gorm:"default:0"`
+	Limit         float64    `json:"limit" gorm:"default:0"`       // IDR per claim, 0 for up to the bus value
+	Status        string     `json:"status" gorm:"default:active"` // active, cancelled, lapsed
+	StartedAt     time.Time  `json:"started_at"`                   // game time
+	NextBillingAt time.Time  `json:"next_billing_at"`              // game time
+	EndedAt       *time.Time `json:"ended_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Bus Bus `json:"bus" gorm:"foreignKey:BusID"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Loan is a bank loan repaid in weekly amortized installments on the game clock.
+type Loan struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	CompanyID         uint       `json:"company_id" gorm:"not null;index"`
+	Principal         float64    `json:"principal" gorm:"not null"`
+	AnnualRate        float64    `json:"annual_rate" gorm:"not null"`
+	Installments      int        `json:"installments" gorm:"not null"`       // weeks
+	InstallmentAmount float64    `json:"installment_amount" gorm:"not null"` // IDR per week
+	Balance           float64    `json:"balance" gorm:"not null"`            // outstanding principal
+	Status            string     `json:"status" gorm:"default:active"`       // active, repaid, defaulted
+	PaymentsMade      int        `json:"payments_made" gorm:"default:0"`
+	PaymentsMissed    int        `json:"payments_missed" gorm:"default:0"`
+	MissedInARow      int        `json:"missed_in_a_row" gorm:"default:0"`
+	TakenAt           time.Time  `json:"taken_at"`    // game time
+	NextDueAt         time.Time  `json:"next_due_at"` // game time
+	ClosedAt          *time.Time `json:"closed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
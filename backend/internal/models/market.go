@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// BusListing offers a company's bus for sale to other players.
+type BusListing struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	SellerCompanyID uint      `json:"seller_company_id" gorm:"not null;index"`
+	BusID           uint      `json:"bus_id" gorm:"not null;index"`
+	AskingPrice     float64   `json:"asking_price" gorm:"not null"`
+	Status          string    `json:"status" gorm:"default:open"` // open, sold, cancelled
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relations
+	Bus    Bus        `json:"bus" gorm:"foreignKey:BusID"`
+	Offers []BusOffer `json:"offers,omitempty" gorm:"foreignKey:ListingID"`
+}
+
+// BusOffer is a buyer's bid on a listing. The amount is held in escrow until
+// the offer is accepted, rejected or withdrawn.
+type BusOffer struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ListingID      uint      `json:"listing_id" gorm:"not null;index"`
+	BuyerCompanyID uint      `json:"buyer_company_id" gorm:"not null;index"`
+	DepotID        uint      `json:"depot_id" gorm:"not null"` // buyer depot receiving the bus
+	Amount         float64   `json:"amount" gorm:"not null"`
+	Status         string    `json:"status" gorm:"default:pending"` // pending, accepted, rejected, withdrawn
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relations
+	Listing BusListing `json:"listing,omitempty" gorm:"foreignKey:ListingID"`
+}
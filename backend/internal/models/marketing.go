@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// MarketingCampaign is a paid campaign boosting demand on the routes serving a
+// city, or on one route, while it runs. The daily budget is charged every game
+// day.
+type MarketingCampaign struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CompanyID   uint      `json:"company_id" gorm:"not null;index"`
+	Channel     string    `json:"channel" gorm:"not null"`     // radio, billboard, social_media
+	TargetType  string    `json:"target_type" gorm:"not null"` // city, route
+	City        string    `json:"city,omitempty"`
+	RouteID     *uint     `json:"route_id,omitempty"`
+	DailyBudget float64   `json:"daily_budget" gorm:"not null"` // IDR
+	Days        int       `json:"days" gorm:"not null"`
+	Boost       float64   `json:"boost" gorm:"not null"`        // demand boost while running, e.g. 0.1 for +10%
+	Status      string    `json:"status" gorm:"default:active"` // active, completed, cancelled
+	StartsAt    time.Time `json:"starts_at"`                    // game time
+	EndsAt      time.Time `json:"ends_at"`                      // game time
+	DaysPaid    int       `json:"days_paid" gorm:"default:0"`
+	Spent       float64   `json:"spent" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Route *Route `json:"route,omitempty" gorm:"foreignKey:RouteID"`
+}
+
+// BusAdvertisement rents a bus livery to an advertiser for a number of game
+// weeks, paid at the end of every week for the distance the bus drove in it.
+type BusAdvertisement struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BusID         uint      `json:"bus_id" gorm:"not null;index;uniqueIndex:idx_active_advertisement,where:status = 'active'"`
+	CompanyID     uint      `json:"company_id" gorm:"not null;index"`
+	Advertiser    string    `json:"advertiser" gorm:"not null"`
+	Weeks         int       `json:"weeks" gorm:"not null"`
+	WeeklyPayment float64   `json:"weekly_payment" gorm:"not null"` // IDR for a full week of driving
+	WeekKm        float64   `json:"week_km" gorm:"default:0"`       // km driven since the last payment
+	Status        string    `json:"status" gorm:"default:active"`   // active, ended, terminated
+	StartsAt      time.Time `json:"starts_at"`                      // game time
+	NextPaymentAt time.Time `json:"next_payment_at"`                // game time
+	PaymentsMade  int       `json:"payments_made" gorm:"default:0"`
+	Earned        float64   `json:"earned" gorm:"default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
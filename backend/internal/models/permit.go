@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// RoutePermit (izin trayek) grants a company the right to operate a route until
+// it expires. Routes only issue a limited number of permits at a time.
+type RoutePermit struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CompanyID      uint       `json:"company_id" gorm:"not null;index"`
+	RouteID        uint       `json:"route_id" gorm:"not null;index"`
+	Status         string     `json:"status" gorm:"default:active"`     // active, expired, revoked
+	Price          float64    `json:"price" gorm:"not null"`            // IDR paid for the latest term
+	IssuedAt       time.Time  `json:"issued_at"`                        // game time
+	ExpiresAt      time.Time  `json:"expires_at"`                       // game time
+	Violations     int        `json:"violations" gorm:"default:0"`      // poor service incidents
+	FareMultiplier float64    `json:"fare_multiplier" gorm:"default:1"` // fare charged as a multiple of the route tariff
+	RevokedAt      *time.Time `json:"revoked_at"`
+	RevokedReason  string     `json:"revoked_reason"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Company Company `json:"-" gorm:"foreignKey:CompanyID"`
+	Route   Route   `json:"route" gorm:"foreignKey:RouteID"`
+}
+
+// PermitBid is an escrowed offer for the next permit slot freed on a full route.
+type PermitBid struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID uint      `json:"company_id" gorm:"not null;index"`
+	RouteID   uint      `json:"route_id" gorm:"not null;index"`
+	Amount    float64   `json:"amount" gorm:"not null"`
+	Status    string    `json:"status" gorm:"default:open"` // open, won, withdrawn
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Route Route `json:"route" gorm:"foreignKey:RouteID"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// RouteReputation is a company's running service-quality score on one route,
+// with the component scores that make it up (all 0-100).
+type RouteReputation struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CompanyID    uint      `json:"company_id" gorm:"not null;uniqueIndex:idx_route_reputation"`
+	RouteID      uint      `json:"route_id" gorm:"not null;uniqueIndex:idx_route_reputation"`
+	Score        float64   `json:"score" gorm:"default:50"`
+	Punctuality  float64   `json:"punctuality" gorm:"default:50"`
+	Reliability  float64   `json:"reliability" gorm:"default:50"`
+	LoadFactor   float64   `json:"load_factor" gorm:"default:50"`
+	Condition    float64   `json:"condition" gorm:"default:50"`
+	Amenities    float64   `json:"amenities" gorm:"default:50"`
+	Satisfaction float64   `json:"satisfaction" gorm:"default:50"`
+	Trips        int       `json:"trips" gorm:"default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relations
+	Route Route `json:"route" gorm:"foreignKey:RouteID"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// TripSatisfaction is the aggregated passenger satisfaction with a completed
+// trip (all 0-100).
+type TripSatisfaction struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TripID      uint      `json:"trip_id" gorm:"not null;uniqueIndex"`
+	CompanyID   uint      `json:"company_id" gorm:"not null;index"`
+	RouteID     uint      `json:"route_id" gorm:"not null;index"`
+	BusID       uint      `json:"bus_id" gorm:"not null;index"`
+	Punctuality float64   `json:"punctuality"`
+	Comfort     float64   `json:"comfort"`
+	Crowding    float64   `json:"crowding"`
+	Price       float64   `json:"price"`
+	Overall     float64   `json:"overall"`
+	GameTime    time.Time `json:"game_time"` // game time the trip arrived
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PassengerReview is a review written by a passenger after a trip.
+type PassengerReview struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TripID    uint      `json:"trip_id" gorm:"not null;index"`
+	CompanyID uint      `json:"company_id" gorm:"not null;index"`
+	RouteID   uint      `json:"route_id" gorm:"not null;index"`
+	BusID     uint      `json:"bus_id" gorm:"not null;index"`
+	Rating    int       `json:"rating" gorm:"not null"`   // 1-5 stars
+	Language  string    `json:"language" gorm:"not null"` // id, en
+	Aspect    string    `json:"aspect"`                   // punctuality, comfort, crowding, price, overall
+	Text      string    `json:"text" gorm:"type:text"`
+	GameTime  time.Time `json:"game_time"`
+	CreatedAt time.Time `json:"created_at"`
+}
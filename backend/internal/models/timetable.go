@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Timetable is a daily departure a company runs on a route. At the departure
+// hour the world dispatches the timetable's bus, or the company's first idle
+// bus when it has none.
+type Timetable struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	CompanyID       uint       `json:"company_id" gorm:"not null;uniqueIndex:idx_timetable_departure"`
+	RouteID         uint       `json:"route_id" gorm:"not null;uniqueIndex:idx_timetable_departure"`
+	DepartureHour   int        `json:"departure_hour" gorm:"not null;uniqueIndex:idx_timetable_departure"` // game hour, WIB
+	BusID           *uint      `json:"bus_id"`
+	AvoidTolls      bool       `json:"avoid_tolls" gorm:"default:false"`
+	LastDepartureAt *time.Time `json:"last_departure_at"` // game time
+	LastTripID      *uint      `json:"last_trip_id"`
+	LastError       string     `json:"last_error"` // why the last departure did not run
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Route Route `json:"route" gorm:"foreignKey:RouteID"`
+	Bus   *Bus  `json:"bus,omitempty" gorm:"foreignKey:BusID"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// RouteCharge is a toll road section or ferry crossing on a route, priced by
+// bus class. Toll sections with a non-toll alternative can be avoided at the
+// price of a longer trip; ferries cannot.
+type RouteCharge struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RouteID     uint      `json:"route_id" gorm:"not null;index"`
+	Sequence    int       `json:"sequence" gorm:"not null"` // order along the route
+	Kind        string    `json:"kind" gorm:"not null"`     // toll, ferry
+	Name        string    `json:"name" gorm:"not null"`
+	FeeSmall    float64   `json:"fee_small" gorm:"not null"`     // IDR
+	FeeMedium   float64   `json:"fee_medium" gorm:"not null"`    // IDR
+	FeeLarge    float64   `json:"fee_large" gorm:"not null"`     // IDR
+	AltDistance float64   `json:"alt_distance" gorm:"default:0"` // extra km on the non-toll alternative
+	AltDuration int       `json:"alt_duration" gorm:"default:0"` // extra minutes on the non-toll alternative, 0 when there is none
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TripCharge is a toll or ferry fee paid on a trip.
+type TripCharge struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TripID    uint      `json:"trip_id" gorm:"not null;index"`
+	Kind      string    `json:"kind" gorm:"not null"` // toll, ferry
+	Name      string    `json:"name" gorm:"not null"`
+	BusClass  string    `json:"bus_class" gorm:"not null"`
+	Amount    float64   `json:"amount" gorm:"not null"` // IDR
+	CreatedAt time.Time `json:"created_at"`
+}
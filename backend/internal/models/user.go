@@ -11,6 +11,7 @@ type User struct {
 	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
 	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
 	Password  string         `json:"-" gorm:"not null"`
+	IsAdmin   bool           `json:"is_admin" gorm:"default:false"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -20,15 +21,17 @@ type User struct {
 }
 
 type Company struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	UserID     uint      `json:"user_id" gorm:"not null"`
-	Name       string    `json:"name" gorm:"not null"`
-	Money      float64   `json:"money" gorm:"default:1000000"` // Starting money in IDR
-	Reputation int       `json:"reputation" gorm:"default:0"`
-	Level      int       `json:"level" gorm:"default:1"`
-	Experience int       `json:"experience" gorm:"default:0"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null"`
+	Money       float64   `json:"money" gorm:"default:2500000000"` // Starting money in IDR
+	Reputation  int       `json:"reputation" gorm:"default:50"`    // 0-100, 50 is neutral
+	Level       int       `json:"level" gorm:"default:1"`
+	Experience  int       `json:"experience" gorm:"default:0"`
+	GreenRating string    `json:"green_rating" gorm:"default:C"`      // A-E from CO2 per passenger-km
+	AIStrategy  string    `json:"ai_strategy,omitempty" gorm:"index"` // strategy of computer-controlled companies, empty for players
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 
 	// Relations
 	Depots []Depot `json:"depots"`
@@ -36,16 +39,21 @@ type Company struct {
 }
 
 type Depot struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	CompanyID    uint      `json:"company_id" gorm:"not null"`
-	Name         string    `json:"name" gorm:"not null"`
-	Latitude     float64   `json:"latitude" gorm:"not null"`
-	Longitude    float64   `json:"longitude" gorm:"not null"`
-	Capacity     int       `json:"capacity" gorm:"default:10"` // Maximum buses
-	CurrentBuses int       `json:"current_buses" gorm:"default:0"`
-	Level        int       `json:"level" gorm:"default:1"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CompanyID      uint      `json:"company_id" gorm:"not null"`
+	Name           string    `json:"name" gorm:"not null"`
+	Latitude       float64   `json:"latitude" gorm:"not null"`
+	Longitude      float64   `json:"longitude" gorm:"not null"`
+	Capacity       int       `json:"capacity" gorm:"default:10"` // Maximum buses
+	CurrentBuses   int       `json:"current_buses" gorm:"default:0"`
+	Level          int       `json:"level" gorm:"default:1"`
+	HasWorkshop    bool      `json:"has_workshop" gorm:"default:false"`     // restores bus condition between trips
+	HasFuelStation bool      `json:"has_fuel_station" gorm:"default:false"` // refuels buses between trips
+	Chargers       int       `json:"chargers" gorm:"default:0"`             // charging stations for electric buses
+	PowerCapacity  float64   `json:"power_capacity" gorm:"default:200"`     // kW the grid connection can supply to chargers
+	Investment     float64   `json:"investment" gorm:"default:0"`           // IDR spent on the depot, basis for resale
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 
 	// Relations
 	Company Company `json:"company" gorm:"foreignKey:CompanyID"`
@@ -53,22 +61,30 @@ type Depot struct {
 }
 
 type Bus struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	CompanyID     uint      `json:"company_id" gorm:"not null"`
-	DepotID       uint      `json:"depot_id" gorm:"not null"`
-	Name          string    `json:"name" gorm:"not null"`
-	Type          string    `json:"type" gorm:"default:normal"` // normal, high_decker, super_high_decker, etc.
-	Capacity      int       `json:"capacity" gorm:"default:40"`
-	FuelCapacity  float64   `json:"fuel_capacity" gorm:"default:100"` // liters
-	CurrentFuel   float64   `json:"current_fuel" gorm:"default:100"`
-	Range         float64   `json:"range" gorm:"default:500"`            // km
-	ServiceType   string    `json:"service_type" gorm:"default:economy"` // economy, business, executive, night
-	Status        string    `json:"status" gorm:"default:available"`     // available, on_trip, maintenance
-	Condition     float64   `json:"condition" gorm:"default:100"`        // percentage
-	PurchasePrice float64   `json:"purchase_price" gorm:"default:0"`
-	OperatingCost float64   `json:"operating_cost" gorm:"default:0"` // per km
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CompanyID     uint           `json:"company_id" gorm:"not null"`
+	DepotID       uint           `json:"depot_id" gorm:"not null"`
+	Name          string         `json:"name" gorm:"not null"`
+	Type          string         `json:"type" gorm:"default:normal"` // normal, high_decker, super_high_decker, etc.
+	Capacity      int            `json:"capacity" gorm:"default:40"`
+	Powertrain    string         `json:"powertrain" gorm:"default:diesel"` // diesel, electric
+	Model         string         `json:"model"`                            // catalog model
+	FuelCapacity  float64        `json:"fuel_capacity" gorm:"default:100"` // liters, or kWh of battery for electric buses
+	CurrentFuel   float64        `json:"current_fuel" gorm:"default:100"`
+	Consumption   float64        `json:"consumption" gorm:"default:0"`        // kWh per km empty with the AC off, electric buses only
+	Range         float64        `json:"range" gorm:"default:500"`            // km
+	ServiceType   string         `json:"service_type" gorm:"default:economy"` // economy, business, executive, night
+	Status        string         `json:"status" gorm:"default:available"`     // available, on_trip, on_charter, maintenance, listed, charging
+	Condition     float64        `json:"condition" gorm:"default:100"`        // percentage
+	PurchasePrice float64        `json:"purchase_price" gorm:"default:0"`
+	OperatingCost float64        `json:"operating_cost" gorm:"default:0"` // per km
+	Odometer      float64        `json:"odometer" gorm:"default:0"`       // km driven
+	PurchasedAt   time.Time      `json:"purchased_at"`                    // game time the bus was first bought
+	ChargingUntil *time.Time     `json:"charging_until"`                  // game time charging completes
+	ChargingPower float64        `json:"charging_power" gorm:"default:0"` // kW drawn while charging
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"` // set when sold to a dealer or scrapped
 
 	// Relations
 	Company  Company      `json:"company" gorm:"foreignKey:CompanyID"`
@@ -78,51 +94,109 @@ type Bus struct {
 }
 
 type Route struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Origin      string    `json:"origin" gorm:"not null"`
-	Destination string    `json:"destination" gorm:"not null"`
-	OriginLat   float64   `json:"origin_lat" gorm:"not null"`
-	OriginLng   float64   `json:"origin_lng" gorm:"not null"`
-	DestLat     float64   `json:"dest_lat" gorm:"not null"`
-	DestLng     float64   `json:"dest_lng" gorm:"not null"`
-	Distance    float64   `json:"distance" gorm:"not null"`      // km
-	Duration    int       `json:"duration" gorm:"not null"`      // minutes
-	Popularity  int       `json:"popularity" gorm:"default:50"`  // 1-100
-	Type        string    `json:"type" gorm:"default:intercity"` // intercity, interprovince
-	MinBusType  string    `json:"min_bus_type" gorm:"default:normal"`
-	BaseFare    float64   `json:"base_fare" gorm:"not null"` // IDR
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"not null"`
+	Origin        string    `json:"origin" gorm:"not null"`
+	Destination   string    `json:"destination" gorm:"not null"`
+	OriginLat     float64   `json:"origin_lat" gorm:"not null"`
+	OriginLng     float64   `json:"origin_lng" gorm:"not null"`
+	DestLat       float64   `json:"dest_lat" gorm:"not null"`
+	DestLng       float64   `json:"dest_lng" gorm:"not null"`
+	Distance      float64   `json:"distance" gorm:"not null"`      // km
+	Duration      int       `json:"duration" gorm:"not null"`      // minutes
+	Popularity    int       `json:"popularity" gorm:"default:50"`  // 1-100
+	Type          string    `json:"type" gorm:"default:intercity"` // intercity, interprovince
+	MinBusType    string    `json:"min_bus_type" gorm:"default:normal"`
+	BaseFare      float64   `json:"base_fare" gorm:"not null"`      // IDR
+	Geometry      string    `json:"geometry" gorm:"type:text"`      // encoded polyline of the road path
+	CompanyID     *uint     `json:"company_id"`                     // company that opened the route, nil for world routes
+	Status        string    `json:"status" gorm:"default:approved"` // pending, approved, rejected
+	LicenseFee    float64   `json:"license_fee" gorm:"default:0"`   // IDR paid to open the route
+	PermitSlots   int       `json:"permit_slots" gorm:"default:3"`  // companies allowed to operate at once
+	PermitPrice   float64   `json:"permit_price" gorm:"default:0"`  // IDR per term, derived from fare when 0
+	MinReputation int       `json:"min_reputation" gorm:"default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
-	Trips []Trip `json:"trips"`
+	Stops   []RouteStop   `json:"stops" gorm:"foreignKey:RouteID"`
+	Charges []RouteCharge `json:"charges" gorm:"foreignKey:RouteID"`
+	Trips   []Trip        `json:"trips"`
+}
+
+// RouteStop is a terminal served by a route. Segment fields describe the leg
+// from the previous stop and are zero for the first stop.
+type RouteStop struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RouteID   uint      `json:"route_id" gorm:"not null;uniqueIndex:idx_route_stop_sequence"`
+	Sequence  int       `json:"sequence" gorm:"not null;uniqueIndex:idx_route_stop_sequence"`
+	City      string    `json:"city" gorm:"not null"`
+	Terminal  string    `json:"terminal"`
+	Latitude  float64   `json:"latitude" gorm:"not null"`
+	Longitude float64   `json:"longitude" gorm:"not null"`
+	Distance  float64   `json:"distance" gorm:"default:0"` // km from previous stop
+	Duration  int       `json:"duration" gorm:"default:0"` // minutes from previous stop
+	Fare      float64   `json:"fare" gorm:"default:0"`     // IDR from previous stop
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Trip struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	BusID       uint      `json:"bus_id" gorm:"not null"`
-	RouteID     uint      `json:"route_id" gorm:"not null"`
-	DriverID    uint      `json:"driver_id"`
-	Status      string    `json:"status" gorm:"default:planned"` // planned, active, completed, cancelled
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	ActualStart time.Time `json:"actual_start"`
-	ActualEnd   time.Time `json:"actual_end"`
-	Passengers  int       `json:"passengers" gorm:"default:0"`
-	Revenue     float64   `json:"revenue" gorm:"default:0"`
-	Cost        float64   `json:"cost" gorm:"default:0"`
-	Profit      float64   `json:"profit" gorm:"default:0"`
-	CurrentLat  float64   `json:"current_lat"`
-	CurrentLng  float64   `json:"current_lng"`
-	Progress    float64   `json:"progress" gorm:"default:0"` // percentage 0-100
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BusID         uint      `json:"bus_id" gorm:"not null"`
+	CompanyID     uint      `json:"company_id" gorm:"not null;default:0;index"` // operator when the trip was dispatched
+	RouteID       uint      `json:"route_id" gorm:"not null"`
+	DriverID      uint      `json:"driver_id"`
+	Status        string    `json:"status" gorm:"default:planned"` // planned, active, completed, cancelled
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	ActualStart   time.Time `json:"actual_start"`
+	ActualEnd     time.Time `json:"actual_end"`
+	Passengers    int       `json:"passengers" gorm:"default:0"`
+	Revenue       float64   `json:"revenue" gorm:"default:0"`
+	Fare          float64   `json:"fare" gorm:"default:0"` // IDR end to end, as set by the operator
+	Cost          float64   `json:"cost" gorm:"default:0"`
+	Profit        float64   `json:"profit" gorm:"default:0"`
+	CurrentLat    float64   `json:"current_lat"`
+	CurrentLng    float64   `json:"current_lng"`
+	Progress      float64   `json:"progress" gorm:"default:0"` // percentage 0-100
+	DelayMinutes  int       `json:"delay_minutes" gorm:"default:0"`
+	Satisfaction  float64   `json:"satisfaction" gorm:"default:0"` // passenger satisfaction 0-100, set on completion
+	AvoidTolls    bool      `json:"avoid_tolls" gorm:"default:false"`
+	DetourKm      float64   `json:"detour_km" gorm:"default:0"`      // extra km driven to avoid tolls
+	DetourMinutes int       `json:"detour_minutes" gorm:"default:0"` // extra minutes driven to avoid tolls
+	EnergyUsed    float64   `json:"energy_used" gorm:"default:0"`    // liters of diesel or kWh
+	CO2           float64   `json:"co2" gorm:"column:co2;default:0"` // kg emitted
+	Powertrain    string    `json:"powertrain"`                      // of the bus when the trip was dispatched
+	PassengerKm   float64   `json:"passenger_km" gorm:"default:0"`
+	CargoKg       float64   `json:"cargo_kg" gorm:"default:0"`      // parcels in the hold
+	CargoRevenue  float64   `json:"cargo_revenue" gorm:"default:0"` // IDR, paid on arrival
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
-	Bus    Bus    `json:"bus" gorm:"foreignKey:BusID"`
-	Route  Route  `json:"route" gorm:"foreignKey:RouteID"`
-	Driver Driver `json:"driver" gorm:"foreignKey:DriverID"`
+	Bus       Bus          `json:"bus" gorm:"foreignKey:BusID"`
+	Route     Route        `json:"route" gorm:"foreignKey:RouteID"`
+	Driver    Driver       `json:"driver" gorm:"foreignKey:DriverID"`
+	Stops     []TripStop   `json:"stops" gorm:"foreignKey:TripID"`
+	Incidents []Incident   `json:"incidents,omitempty" gorm:"foreignKey:TripID"`
+	Charges   []TripCharge `json:"charges" gorm:"foreignKey:TripID"`
+	Parcels   []TripParcel `json:"parcels" gorm:"foreignKey:TripID"`
+}
+
+type TripStop struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TripID    uint       `json:"trip_id" gorm:"not null;index"`
+	Sequence  int        `json:"sequence" gorm:"not null"`
+	City      string     `json:"city" gorm:"not null"`
+	Terminal  string     `json:"terminal"`
+	Boarding  int        `json:"boarding" gorm:"default:0"`
+	Alighting int        `json:"alighting" gorm:"default:0"`
+	OnBoard   int        `json:"on_board" gorm:"default:0"`     // passengers after departure
+	Revenue   float64    `json:"revenue" gorm:"default:0"`      // fares from passengers boarding here
+	Status    string     `json:"status" gorm:"default:pending"` // pending, arrived
+	ArrivedAt *time.Time `json:"arrived_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 type Driver struct {
@@ -158,10 +232,14 @@ type BusUpgrade struct {
 	Bus Bus `json:"bus" gorm:"foreignKey:BusID"`
 }
 
+// Transaction is an entry in a company's ledger. Its Type is one of income,
+// expense, purchase, sale, reward, incident, insurance, toll, ferry,
+// electricity, fuel, carbon_tax, subsidy, penalty, charter, cargo, marketing,
+// advertising, fare or operating.
 type Transaction struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	CompanyID   uint      `json:"company_id" gorm:"not null"`
-	Type        string    `json:"type" gorm:"not null"` // income, expense, purchase, sale
+	Type        string    `json:"type" gorm:"not null"` // see the list above
 	Description string    `json:"description" gorm:"not null"`
 	Amount      float64   `json:"amount" gorm:"not null"`
 	Balance     float64   `json:"balance" gorm:"not null"` // Company balance after transaction
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// WorldState is a single row holding server-wide simulation state.
+type WorldState struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	GameTime  time.Time `json:"game_time"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,98 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Domain events the achievement engine listens to.
+const (
+	EventTripCompleted = "trip_completed"
+	EventBusBought     = "bus_bought"
+	EventRouteOpened   = "route_opened"
+	EventLevelReached  = "level_reached"
+)
+
+// GameEvent is something a company did, with the measurable values attached
+// to it (e.g. passengers carried on a trip).
+type GameEvent struct {
+	Type   string             `json:"type"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Achievement is a goal loaded from configuration. Quests are achievements
+// that only become available once the quest they require is completed.
+type Achievement struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"` // achievement, quest
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Event       string `json:"event"`
+	// Metric is the event value that counts toward the target; "count" counts
+	// the events themselves.
+	Metric string  `json:"metric"`
+	Mode   string  `json:"mode"` // sum (default) or max
+	Target float64 `json:"target"`
+	// Filters must all be met by the event for it to count, e.g. on_time >= 1.
+	Filters  map[string]float64 `json:"filters,omitempty"`
+	Requires string             `json:"requires,omitempty"`
+	Reward   AchievementReward  `json:"reward"`
+}
+
+type AchievementReward struct {
+	Money      float64 `json:"money"`
+	Experience int     `json:"experience"`
+}
+
+func ParseAchievements(raw []byte) ([]Achievement, error) {
+	var list struct {
+		Achievements []Achievement `json:"achievements"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse achievements: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, a := range list.Achievements {
+		if a.ID == "" || a.Event == "" || a.Target <= 0 {
+			return nil, fmt.Errorf("achievement %d needs an id, event and positive target", i)
+		}
+		if seen[a.ID] {
+			return nil, fmt.Errorf("duplicate achievement %q", a.ID)
+		}
+		if a.Requires != "" && !seen[a.Requires] {
+			return nil, fmt.Errorf("achievement %q requires %q, which must be listed before it", a.ID, a.Requires)
+		}
+		seen[a.ID] = true
+		if list.Achievements[i].Kind == "" {
+			list.Achievements[i].Kind = "achievement"
+		}
+		if list.Achievements[i].Metric == "" {
+			list.Achievements[i].Metric = "count"
+		}
+	}
+	return list.Achievements, nil
+}
+
+// Progress applies an event to the current progress toward the achievement.
+// The second result is false when the event does not count.
+func (a Achievement) Progress(current float64, e GameEvent) (float64, bool) {
+	if e.Type != a.Event {
+		return current, false
+	}
+	for key, min := range a.Filters {
+		if e.Values[key] < min {
+			return current, false
+		}
+	}
+
+	value := 1.0
+	if a.Metric != "count" {
+		value = e.Values[a.Metric]
+	}
+	if a.Mode == "max" {
+		return math.Max(current, value), true
+	}
+	return current + value, true
+}
@@ -0,0 +1,134 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Route preferences of AI strategies.
+const (
+	AIRoutesPopular   = "popular"    // the busiest routes first
+	AIRoutesShortHaul = "short_haul" // short, cheap hops first
+	AIRoutesLongHaul  = "long_haul"  // long, high-fare routes first
+)
+
+// firstAIDepartureHour is when the first of an AI company's daily departures
+// on a route leaves; the rest are spread over the day.
+const firstAIDepartureHour = 5
+
+// AIStrategy drives the decisions of a computer-controlled company.
+type AIStrategy struct {
+	Strategy            string   `json:"strategy"`
+	Name                string   `json:"name"`
+	CompanyNames        []string `json:"company_names"`
+	StartingCapital     float64  `json:"starting_capital"` // IDR, including the players' starting money
+	CashReserve         float64  `json:"cash_reserve"`     // IDR kept back when expanding
+	ServiceType         string   `json:"service_type"`
+	Buses               []string `json:"buses"` // diesel catalog models, preferred first; the first unlocked one is bought
+	Routes              string   `json:"routes"`
+	MaxRoutes           int      `json:"max_routes"`
+	BusesPerRoute       int      `json:"buses_per_route"`
+	MaxBuses            int      `json:"max_buses"`
+	FareMultiplier      float64  `json:"fare_multiplier"` // fare charged as a multiple of the route tariff
+	DeparturesPerDay    int      `json:"departures_per_day"`
+	MinCondition        float64  `json:"min_condition"` // buses below this are sold
+	Borrow              bool     `json:"borrow"`        // takes loans to keep expanding
+	ActionIntervalHours int      `json:"action_interval_hours"`
+}
+
+// AIStrategies is the list of AI strategies loaded from configuration.
+type AIStrategies struct {
+	Strategies []AIStrategy `json:"strategies"`
+}
+
+func ParseAIStrategies(raw []byte) (*AIStrategies, error) {
+	var s AIStrategies
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse AI strategies: %w", err)
+	}
+	if len(s.Strategies) == 0 {
+		return nil, fmt.Errorf("AI configuration needs at least one strategy")
+	}
+
+	seen := make(map[string]bool)
+	for _, strategy := range s.Strategies {
+		if strategy.Strategy == "" || seen[strategy.Strategy] {
+			return nil, fmt.Errorf("AI strategy %q needs a unique id", strategy.Strategy)
+		}
+		seen[strategy.Strategy] = true
+		if len(strategy.CompanyNames) == 0 {
+			return nil, fmt.Errorf("AI strategy %q needs at least one company name", strategy.Strategy)
+		}
+		if len(strategy.Buses) == 0 {
+			return nil, fmt.Errorf("AI strategy %q needs at least one bus", strategy.Strategy)
+		}
+		for _, model := range strategy.Buses {
+			if _, ok := FindDieselBusModel(model); !ok {
+				return nil, fmt.Errorf("AI strategy %q buys an unknown bus model %q", strategy.Strategy, model)
+			}
+		}
+		switch strategy.Routes {
+		case AIRoutesPopular, AIRoutesShortHaul, AIRoutesLongHaul:
+		default:
+			return nil, fmt.Errorf("AI strategy %q has an unknown route preference %q", strategy.Strategy, strategy.Routes)
+		}
+		if strategy.FareMultiplier < MinFareMultiplier || strategy.FareMultiplier > MaxFareMultiplier {
+			return nil, fmt.Errorf("AI strategy %q needs a fare multiplier between %.1f and %.1f", strategy.Strategy, MinFareMultiplier, MaxFareMultiplier)
+		}
+		if strategy.DeparturesPerDay < 1 || strategy.DeparturesPerDay > 24 {
+			return nil, fmt.Errorf("AI strategy %q needs between 1 and 24 departures per day", strategy.Strategy)
+		}
+		if strategy.MaxRoutes < 1 || strategy.BusesPerRoute < 1 || strategy.MaxBuses < 1 || strategy.ActionIntervalHours < 1 {
+			return nil, fmt.Errorf("AI strategy %q needs positive route, fleet and interval limits", strategy.Strategy)
+		}
+	}
+	return &s, nil
+}
+
+// Find returns the strategy with the given id.
+func (s *AIStrategies) Find(strategy string) (AIStrategy, bool) {
+	for _, st := range s.Strategies {
+		if st.Strategy == strategy {
+			return st, true
+		}
+	}
+	return AIStrategy{}, false
+}
+
+// CompanyName names the n-th company (counting from zero) run by the strategy.
+// Names are reused with a number once the list runs out.
+func (s AIStrategy) CompanyName(n int) string {
+	name := s.CompanyNames[n%len(s.CompanyNames)]
+	if round := n / len(s.CompanyNames); round > 0 {
+		name = fmt.Sprintf("%s %d", name, round+1)
+	}
+	return name
+}
+
+// RouteScore ranks a route for the strategy; higher scores are preferred.
+func (s AIStrategy) RouteScore(popularity int, distance float64) float64 {
+	switch s.Routes {
+	case AIRoutesShortHaul:
+		return float64(popularity) / math.Max(distance, 1)
+	case AIRoutesLongHaul:
+		return distance * float64(popularity) / 100
+	default:
+		return float64(popularity)
+	}
+}
+
+// FleetTarget is how many buses the strategy wants running on routes routes.
+func (s AIStrategy) FleetTarget(routes int) int {
+	return min(s.MaxBuses, routes*s.BusesPerRoute)
+}
+
+// DepartureHours spreads the strategy's daily departures on a route evenly
+// over the day.
+func (s AIStrategy) DepartureHours() []int {
+	hours := make([]int, s.DeparturesPerDay)
+	for i := range hours {
+		hours[i] = (firstAIDepartureHour + i*24/s.DeparturesPerDay) % 24
+	}
+	return hours
+}
@@ -0,0 +1,52 @@
+package simulation
+
+import "math"
+
+const (
+	DieselTankLiters = 100.0
+	DieselRangeKm    = 500.0
+)
+
+// DieselBusModel is a diesel bus sold in the catalog.
+type DieselBusModel struct {
+	Model    string  `json:"model"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"` // bus type, for the level unlocks
+	Capacity int     `json:"capacity"`
+	Price    float64 `json:"price"` // IDR
+}
+
+var DieselBusModels = []DieselBusModel{
+	{"isuzu-nqr71", "Isuzu NQR 71 medium bus", "normal", 29, 950000000},
+	{"hino-rk8", "Hino RK8 R260", "normal", 40, 1450000000},
+	{"mercedes-oh1526", "Mercedes-Benz OH 1526", "normal", 45, 1650000000},
+	{"hino-rn285-hdd", "Hino RN 285 high decker", "high_decker", 40, 2100000000},
+	{"scania-k360-shd", "Scania K360IB super high decker", "super_high_decker", 34, 2900000000},
+	{"scania-k410-sdd", "Scania K410IB double decker", "double_decker", 60, 4200000000},
+}
+
+func FindDieselBusModel(model string) (DieselBusModel, bool) {
+	for _, m := range DieselBusModels {
+		if m.Model == model {
+			return m, true
+		}
+	}
+	return DieselBusModel{}, false
+}
+
+// DieselBusModelFor is the diesel catalog model of busType whose capacity is
+// closest to capacity, or the first one of the type when capacity is 0.
+func DieselBusModelFor(busType string, capacity int) (DieselBusModel, bool) {
+	var best DieselBusModel
+	found := false
+	for _, m := range DieselBusModels {
+		if m.Type != busType {
+			continue
+		}
+		if !found || (capacity > 0 && math.Abs(float64(m.Capacity-capacity)) < math.Abs(float64(best.Capacity-capacity))) {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,61 @@
+package simulation
+
+import "math"
+
+const (
+	LuggagePerPassengerKg = 15.0 // hold space taken by each passenger's luggage
+
+	parcelBaseFee         = 2000.0 // IDR per kg
+	parcelFeePerKm        = 15.0   // IDR per kg per km
+	parcelBaseDemandKg    = 50.0   // consigned for the final terminal on a fully popular route
+	parcelDemandPerKm     = 0.5    // extra kg consigned per km of distance
+	parcelIntermediateCut = 0.5    // intermediate stops receive this share of the terminal's parcels
+)
+
+// holdCapacityKg is the luggage hold of each bus class.
+var holdCapacityKg = map[string]float64{
+	BusClassSmall:  400,
+	BusClassMedium: 800,
+	BusClassLarge:  1200,
+}
+
+// HoldCapacity is the luggage hold in kg of a bus with the given seat count.
+func HoldCapacity(capacity int) float64 {
+	return holdCapacityKg[BusClass(capacity)]
+}
+
+// ParcelLoad is the parcel weight consigned at the route origin for one stop.
+type ParcelLoad struct {
+	Stop     int     `json:"stop"` // index into the route's stops
+	WeightKg float64 `json:"weight_kg"`
+	Fee      float64 `json:"fee"` // IDR
+}
+
+// ParcelFee is the shipping fee for weight kg carried distance km.
+func ParcelFee(weight, distance float64) float64 {
+	return math.Round(weight * (parcelBaseFee + parcelFeePerKm*distance))
+}
+
+// LoadParcels fills the hold space left by passenger luggage with parcels
+// consigned at the origin. distances[i] is the km from the origin to stop i+1;
+// the last entry is the final terminal. Parcels for nearer stops are loaded
+// first. It returns the loads, the total weight and the total fees.
+func LoadParcels(holdKg float64, popularity int, distances []float64) ([]ParcelLoad, float64, float64) {
+	var loads []ParcelLoad
+	weight, revenue := 0.0, 0.0
+	for i, distance := range distances {
+		demand := (parcelBaseDemandKg + parcelDemandPerKm*distance) * float64(popularity) / 100
+		if i < len(distances)-1 {
+			demand *= parcelIntermediateCut
+		}
+		load := math.Floor(math.Min(demand, holdKg-weight))
+		if load <= 0 {
+			continue
+		}
+		fee := ParcelFee(load, distance)
+		loads = append(loads, ParcelLoad{Stop: i + 1, WeightKg: load, Fee: fee})
+		weight += load
+		revenue += fee
+	}
+	return loads, weight, revenue
+}
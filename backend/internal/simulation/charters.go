@@ -0,0 +1,110 @@
+package simulation
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+const (
+	charterWaitingRate     = 75000.0 // IDR per hour the bus waits at the destination
+	charterCancellationFee = 0.25    // share of the pay owed when a booked job is not run
+	charterDepartureHour   = 7
+	charterMinLeadDays     = 3
+	charterMaxLeadDays     = 10
+)
+
+// CharterKind is a type of charter job posted on the job board.
+type CharterKind struct {
+	Kind          string   `json:"kind"`
+	Name          string   `json:"name"`
+	Clients       []string `json:"-"`
+	MinPassengers int      `json:"min_passengers"`
+	MaxPassengers int      `json:"max_passengers"`
+	ServiceType   string   `json:"service_type,omitempty"` // required service, empty for any
+	RatePerKm     float64  `json:"rate_per_km"`            // IDR per km driven
+	MinDwellHours int      `json:"min_dwell_hours"`
+	MaxDwellHours int      `json:"max_dwell_hours"`
+	MinKm         float64  `json:"min_km"` // one-way road distance
+	MaxKm         float64  `json:"max_km"`
+}
+
+var CharterKinds = []CharterKind{
+	{
+		Kind: "school_trip", Name: "School trip",
+		Clients:       []string{"SMA Negeri 3", "SMP Al-Azhar", "SD Tunas Harapan", "SMK Bina Nusantara"},
+		MinPassengers: 30, MaxPassengers: 45,
+		RatePerKm:     12000,
+		MinDwellHours: 4, MaxDwellHours: 8,
+		MinKm: 50, MaxKm: 250,
+	},
+	{
+		Kind: "corporate_event", Name: "Corporate outing",
+		Clients:       []string{"PT Telkom Indonesia", "Bank Mandiri", "PT Astra International", "PT Unilever Indonesia"},
+		MinPassengers: 20, MaxPassengers: 40,
+		ServiceType:   "executive",
+		RatePerKm:     18000,
+		MinDwellHours: 6, MaxDwellHours: 10,
+		MinKm: 80, MaxKm: 300,
+	},
+	{
+		Kind: "wedding", Name: "Wedding guests",
+		Clients:       []string{"Keluarga Wijaya", "Keluarga Siregar", "Keluarga Nugroho", "Keluarga Lubis"},
+		MinPassengers: 15, MaxPassengers: 30,
+		RatePerKm:     14000,
+		MinDwellHours: 3, MaxDwellHours: 6,
+		MinKm: 30, MaxKm: 150,
+	},
+	{
+		Kind: "pilgrimage", Name: "Ziarah tour",
+		Clients:       []string{"Majelis Taklim Al-Hidayah", "Jamaah Ziarah Wali Songo", "Pengajian Nurul Iman"},
+		MinPassengers: 40, MaxPassengers: 55,
+		RatePerKm:     11000,
+		MinDwellHours: 8, MaxDwellHours: 12,
+		MinKm: 150, MaxKm: 400,
+	},
+}
+
+// CharterDraw is a charter job drawn for the job board. The caller picks the
+// destination among the cities in the kind's distance range using Pick.
+type CharterDraw struct {
+	Kind       CharterKind
+	Client     string
+	Passengers int
+	DwellHours int
+	StartsAt   time.Time
+	Pick       float64 // 0-1, selects the origin and destination pair
+}
+
+// DrawCharter draws the job posted in a board slot on a game day. Draws are
+// seeded by date and slot, so the board is the same whenever it is generated.
+func DrawCharter(day time.Time, slot int) CharterDraw {
+	day = day.In(GameStart.Location())
+	h := fnv.New64a()
+	h.Write([]byte("charter|" + day.Format("2006-01-02") + "|" + strconv.Itoa(slot)))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	kind := CharterKinds[rng.Intn(len(CharterKinds))]
+	lead := charterMinLeadDays + rng.Intn(charterMaxLeadDays-charterMinLeadDays+1)
+	return CharterDraw{
+		Kind:       kind,
+		Client:     kind.Clients[rng.Intn(len(kind.Clients))],
+		Passengers: kind.MinPassengers + rng.Intn(kind.MaxPassengers-kind.MinPassengers+1),
+		DwellHours: kind.MinDwellHours + rng.Intn(kind.MaxDwellHours-kind.MinDwellHours+1),
+		StartsAt:   time.Date(day.Year(), day.Month(), day.Day()+lead, charterDepartureHour, 0, 0, 0, day.Location()),
+		Pick:       rng.Float64(),
+	}
+}
+
+// Pay is what the client pays for a round trip of distance km with the
+// bus waiting dwellHours at the destination.
+func (k CharterKind) Pay(distance float64, dwellHours int) float64 {
+	return math.Round((distance*k.RatePerKm+float64(dwellHours)*charterWaitingRate)/10000) * 10000
+}
+
+// CharterCancellationFee is owed when a booked job paying pay is not run.
+func CharterCancellationFee(pay float64) float64 {
+	return math.Round(pay * charterCancellationFee)
+}
@@ -0,0 +1,47 @@
+package simulation
+
+import (
+	"sync"
+	"time"
+)
+
+// TimeScale is the number of game seconds that pass per real second. Trip
+// simulation already treats one real second as one game minute.
+const TimeScale = 60
+
+const GameDay = 24 * time.Hour
+
+// GameStart is the game time of a brand new world.
+var GameStart = time.Date(2025, time.January, 1, 6, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+
+var (
+	clockMu  sync.RWMutex
+	gameTime = GameStart
+)
+
+// Now returns the current game time.
+func Now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return gameTime
+}
+
+// SetNow restores the game time, e.g. from the persisted world state.
+func SetNow(t time.Time) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	gameTime = t
+}
+
+// Advance moves the game clock forward by d of game time and returns the new time.
+func Advance(d time.Duration) time.Time {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	gameTime = gameTime.Add(d)
+	return gameTime
+}
+
+// RealDuration converts a span of game time into wall-clock time.
+func RealDuration(d time.Duration) time.Duration {
+	return d / TimeScale
+}
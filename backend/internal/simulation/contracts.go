@@ -0,0 +1,58 @@
+package simulation
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// PioneerMaxPopularity is the most popular a route may be to qualify for a
+// pioneer (angkutan perintis) subsidy contract.
+const PioneerMaxPopularity = 45
+
+const (
+	contractBaseRatePerKm = 2000.0 // IDR per km of a departure on the least remote qualifying route
+	contractPenaltyRatio  = 0.5    // penalty per missed departure as a share of its payment
+	contractMaxMissRatio  = 0.25   // contracts are terminated past this share of missed departures
+)
+
+var contractDurations = []int{14, 30, 60}
+
+// ContractTerms are the obligations and payments of a subsidy contract.
+type ContractTerms struct {
+	DailyDepartures     int     `json:"daily_departures"`
+	Days                int     `json:"days"`
+	PaymentPerDeparture float64 `json:"payment_per_departure"` // IDR
+	PenaltyPerMissed    float64 `json:"penalty_per_missed"`    // IDR
+}
+
+// RequiredDepartures is the number of departures over the whole contract.
+func (t ContractTerms) RequiredDepartures() int {
+	return t.DailyDepartures * t.Days
+}
+
+// MaxMissed is the number of missed departures after which the contract is
+// terminated.
+func (t ContractTerms) MaxMissed() int {
+	return int(math.Ceil(float64(t.RequiredDepartures()) * contractMaxMissRatio))
+}
+
+// PioneerContractTerms draws the terms the government offers for a route on
+// the given day. The less popular the route, the higher the subsidy per km.
+// Terms are seeded by route and date, so an offer is the same whenever it is
+// generated.
+func PioneerContractTerms(routeID uint, distance float64, popularity int, day time.Time) ContractTerms {
+	h := fnv.New64a()
+	h.Write([]byte(day.In(GameStart.Location()).Format("2006-01-02")))
+	rng := rand.New(rand.NewSource(int64(h.Sum64()) + int64(routeID)))
+
+	remoteness := 1 + float64(PioneerMaxPopularity-popularity)/PioneerMaxPopularity
+	payment := math.Round(distance*contractBaseRatePerKm*remoteness/1000) * 1000
+	return ContractTerms{
+		DailyDepartures:     1 + rng.Intn(2),
+		Days:                contractDurations[rng.Intn(len(contractDurations))],
+		PaymentPerDeparture: payment,
+		PenaltyPerMissed:    math.Round(payment * contractPenaltyRatio),
+	}
+}
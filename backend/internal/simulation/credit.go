@@ -0,0 +1,70 @@
+package simulation
+
+import "math"
+
+const (
+	baseCreditScore      = 60
+	onTimePaymentBonus   = 2
+	maxOnTimeBonus       = 30
+	missedPaymentPenalty = 15
+	debtRatioPenalty     = 40 // score lost when debt equals company value
+	maxLoanValueRatio    = 0.5
+	baseAnnualRate       = 0.08
+	maxRiskPremium       = 0.17 // added to the base rate for the worst credit score
+)
+
+// CreditScore rates a company 0-100 from its repayment history and current
+// leverage (outstanding debt divided by company value).
+func CreditScore(paymentsMade, paymentsMissed int, debtRatio float64) int {
+	score := float64(baseCreditScore)
+	score += math.Min(float64(paymentsMade*onTimePaymentBonus), maxOnTimeBonus)
+	score -= float64(paymentsMissed * missedPaymentPenalty)
+	score -= math.Max(0, debtRatio) * debtRatioPenalty
+	return int(math.Max(0, math.Min(100, math.Round(score))))
+}
+
+// CreditGrade maps a credit score to a letter rating.
+func CreditGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "AAA"
+	case score >= 80:
+		return "AA"
+	case score >= 70:
+		return "A"
+	case score >= 60:
+		return "BBB"
+	case score >= 50:
+		return "BB"
+	case score >= 40:
+		return "B"
+	case score >= 25:
+		return "CCC"
+	default:
+		return "D"
+	}
+}
+
+// LoanLimit returns the maximum new borrowing and the annual interest rate
+// offered to a company. Companies rated D cannot borrow.
+func LoanLimit(companyValue, outstandingDebt float64, score int) (float64, float64) {
+	rate := baseAnnualRate + maxRiskPremium*float64(100-score)/100
+	if CreditGrade(score) == "D" {
+		return 0, rate
+	}
+	scoreFactor := float64(score) / 100
+	limit := companyValue*maxLoanValueRatio*scoreFactor - outstandingDebt
+	return math.Max(0, math.Floor(limit/100000)*100000), rate
+}
+
+// Installment is the fixed payment that amortizes principal over n periods at
+// the given rate per period.
+func Installment(principal, periodRate float64, n int) float64 {
+	if n <= 0 {
+		return principal
+	}
+	if periodRate == 0 {
+		return principal / float64(n)
+	}
+	return principal * periodRate / (1 - math.Pow(1+periodRate, -float64(n)))
+}
@@ -0,0 +1,115 @@
+package simulation
+
+import "math"
+
+// Boarding demand at intermediate stops relative to the route origin, and the
+// relative attractiveness of the final terminal as a destination.
+const (
+	intermediateBoardingWeight = 0.5
+	terminalDestinationWeight  = 2.0
+)
+
+// Operators set their fare as a multiple of the route tariff within these
+// limits. Demand falls faster than the fare rises.
+const (
+	MinFareMultiplier = 0.5
+	MaxFareMultiplier = 2.0
+	fareElasticity    = 1.2
+)
+
+// StopLoad is the passenger movement at one stop of a trip.
+type StopLoad struct {
+	Boarding  int     `json:"boarding"`
+	Alighting int     `json:"alighting"`
+	OnBoard   int     `json:"on_board"` // after departing the stop
+	Revenue   float64 `json:"revenue"`  // fares paid by passengers boarding here
+}
+
+// LoadStops distributes passengers over an ordered list of stops. segmentFares[i]
+// is the fare for travelling from stop i to stop i+1, so a route with n stops has
+// n-1 segment fares. Passengers board at every stop except the last, pick a later
+// stop as destination and are limited by the seats free at departure.
+func LoadStops(capacity, popularity int, segmentFares []float64) ([]StopLoad, int, float64) {
+	n := len(segmentFares) + 1
+	loads := make([]StopLoad, n)
+	alightAt := make([]int, n)
+	onBoard := 0
+	passengers := 0
+	revenue := 0.0
+
+	for i := 0; i < n; i++ {
+		loads[i].Alighting = alightAt[i]
+		onBoard -= alightAt[i]
+
+		if i < n-1 {
+			weight := 1.0
+			if i > 0 {
+				weight = intermediateBoardingWeight
+			}
+			demand := int(float64(capacity) * float64(popularity) / 100.0 * weight)
+			boarding := int(math.Min(float64(demand), float64(capacity-onBoard)))
+			if boarding < 0 {
+				boarding = 0
+			}
+
+			for j, count := range splitByDestination(boarding, i, n) {
+				if count == 0 {
+					continue
+				}
+				alightAt[j] += count
+				loads[i].Revenue += float64(count) * fareBetween(segmentFares, i, j)
+			}
+
+			loads[i].Boarding = boarding
+			onBoard += boarding
+			passengers += boarding
+			revenue += loads[i].Revenue
+		}
+
+		loads[i].OnBoard = onBoard
+	}
+
+	return loads, passengers, revenue
+}
+
+// splitByDestination assigns passengers boarding at stop origin to the later
+// stops, with any rounding remainder travelling to the final terminal.
+func splitByDestination(boarding, origin, n int) map[int]int {
+	weights := make(map[int]float64)
+	total := 0.0
+	for j := origin + 1; j < n; j++ {
+		w := 1.0
+		if j == n-1 {
+			w = terminalDestinationWeight
+		}
+		weights[j] = w
+		total += w
+	}
+
+	split := make(map[int]int)
+	assigned := 0
+	for j := origin + 1; j < n-1; j++ {
+		count := int(float64(boarding) * weights[j] / total)
+		split[j] = count
+		assigned += count
+	}
+	split[n-1] = boarding - assigned
+	return split
+}
+
+func fareBetween(segmentFares []float64, from, to int) float64 {
+	fare := 0.0
+	for k := from; k < to; k++ {
+		fare += segmentFares[k]
+	}
+	return fare
+}
+
+// FareDemand scales demand on a route for a fare set at multiplier times the
+// route tariff: cheaper tickets draw more passengers, dearer ones fewer.
+func FareDemand(multiplier float64) float64 {
+	if multiplier <= 0 {
+		return 1
+	}
+	return math.Pow(multiplier, -fareElasticity)
+}
@@ -0,0 +1,26 @@
+package simulation
+
+import "math"
+
+const (
+	yearlyDepreciation = 0.15      // value lost per game year of age
+	busLifetimeKm      = 1000000.0 // distance after which mileage no longer lowers value
+	minMileageFactor   = 0.2
+	scrapValueRatio    = 0.05 // scrap metal value as a share of the purchase price
+)
+
+// BusValue estimates the market value of a bus from its purchase price, age in
+// game days, odometer reading in km and condition percentage.
+func BusValue(purchasePrice, ageDays, km, condition float64) float64 {
+	ageFactor := math.Pow(1-yearlyDepreciation, ageDays/365)
+	mileageFactor := math.Max(minMileageFactor, 1-km/busLifetimeKm)
+	conditionFactor := 0.5 + 0.5*math.Max(0, math.Min(condition, 100))/100
+
+	value := purchasePrice * ageFactor * mileageFactor * conditionFactor
+	return math.Max(value, ScrapValue(purchasePrice))
+}
+
+// ScrapValue is what a scrapyard pays for a bus regardless of its state.
+func ScrapValue(purchasePrice float64) float64 {
+	return purchasePrice * scrapValueRatio
+}
@@ -0,0 +1,78 @@
+package simulation
+
+import (
+	"math"
+	"time"
+)
+
+// Powertrains. Diesel buses measure FuelCapacity and CurrentFuel in liters,
+// electric buses in kWh of battery.
+const (
+	PowertrainDiesel   = "diesel"
+	PowertrainElectric = "electric"
+)
+
+const (
+	DieselKmPerLiter  = 10.0
+	DieselPrice       = 6800.0 // IDR per liter at the depot fuel station
+	ElectricityTariff = 1450.0 // IDR per kWh at the depot
+	ChargerPowerKW    = 150.0  // output of one depot charging station
+
+	fullLoadConsumption = 0.25 // extra consumption with every seat taken
+	acConsumption       = 0.15 // extra consumption with the air conditioning on
+)
+
+// ElectricBusModel is a battery electric bus sold in the catalog.
+type ElectricBusModel struct {
+	Model       string  `json:"model"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"` // bus type, for the level unlocks
+	Capacity    int     `json:"capacity"`
+	Price       float64 `json:"price"`       // IDR
+	BatteryKWh  float64 `json:"battery_kwh"` // usable capacity
+	Consumption float64 `json:"consumption"` // kWh per km empty with the AC off
+	MaxChargeKW float64 `json:"max_charge_kw"`
+}
+
+// Range is the distance the model covers on a full battery, empty with the AC off.
+func (m ElectricBusModel) Range() float64 {
+	return math.Round(m.BatteryKWh / m.Consumption)
+}
+
+var ElectricBusModels = []ElectricBusModel{
+	{"inka-e-inobus", "INKA E-Inobus", "normal", 30, 2800000000, 250, 0.9, 120},
+	{"mab-md12e", "MAB MD12E", "normal", 40, 3600000000, 324, 1.1, 150},
+	{"byd-c9", "BYD C9", "high_decker", 45, 5200000000, 422, 1.2, 200},
+}
+
+func FindElectricBusModel(model string) (ElectricBusModel, bool) {
+	for _, m := range ElectricBusModels {
+		if m.Model == model {
+			return m, true
+		}
+	}
+	return ElectricBusModel{}, false
+}
+
+// EnergyPerKm is what an electric bus draws per km with the given load and AC
+// use; passengers and air conditioning both shorten the range.
+func EnergyPerKm(consumption float64, passengers, capacity int, ac bool) float64 {
+	load := 0.0
+	if capacity > 0 {
+		load = math.Min(1, float64(passengers)/float64(capacity))
+	}
+	perKm := consumption * (1 + fullLoadConsumption*load)
+	if ac {
+		perKm *= 1 + acConsumption
+	}
+	return perKm
+}
+
+// ChargeDuration is the game time needed to put energy kWh into a battery at
+// power kW.
+func ChargeDuration(energy, power float64) time.Duration {
+	if power <= 0 {
+		return 0
+	}
+	return time.Duration(energy / power * float64(time.Hour))
+}
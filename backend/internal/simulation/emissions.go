@@ -0,0 +1,84 @@
+package simulation
+
+import "math"
+
+const (
+	DieselCO2PerLiter = 2.68 // kg of CO2 per liter of diesel burned
+	GridCO2PerKWh     = 0.1  // kg per kWh; depots buy renewable-certified power from PLN
+
+	// DefaultCarbonTaxPerTonne is Indonesia's carbon tax floor in IDR per tonne of CO2.
+	DefaultCarbonTaxPerTonne = 30000.0
+)
+
+// NeutralGreenRating is the grade of companies without recent trips.
+const NeutralGreenRating = "C"
+
+// GreenRating grades a company's CO2 intensity in grams per passenger-km.
+type GreenRating struct {
+	Grade        string  `json:"grade"`
+	MaxIntensity float64 `json:"max_intensity"` // g CO2 per passenger-km, 0 for the last grade
+	DemandBonus  float64 `json:"demand_bonus"`  // demand change among eco-conscious passengers
+	Subsidy      float64 `json:"subsidy"`       // IDR per passenger-km carried on zero-emission buses
+}
+
+var GreenRatings = []GreenRating{
+	{"A", 5, 0.3, 2},
+	{"B", 8, 0.15, 1},
+	{"C", 12, 0, 0},
+	{"D", 20, -0.1, 0},
+	{"E", 0, -0.2, 0},
+}
+
+// ecoConsciousShare is the share of passengers choosing an operator on its
+// green record, by service type.
+var ecoConsciousShare = map[string]float64{
+	"economy":   0.1,
+	"business":  0.3,
+	"executive": 0.4,
+	"night":     0.2,
+}
+
+// TripCO2 is the CO2 in kg emitted for energy liters of diesel or kWh of
+// electricity.
+func TripCO2(powertrain string, energy float64) float64 {
+	factor := DieselCO2PerLiter
+	if powertrain == PowertrainElectric {
+		factor = GridCO2PerKWh
+	}
+	return math.Round(energy*factor*100) / 100
+}
+
+// EmissionIntensity is CO2 in grams per passenger-km.
+func EmissionIntensity(co2, passengerKm float64) float64 {
+	if passengerKm <= 0 {
+		return 0
+	}
+	return co2 * 1000 / passengerKm
+}
+
+// RateEmissions returns the green rating for a CO2 intensity in grams per
+// passenger-km.
+func RateEmissions(intensity float64) GreenRating {
+	for _, r := range GreenRatings {
+		if r.MaxIntensity > 0 && intensity <= r.MaxIntensity {
+			return r
+		}
+	}
+	return GreenRatings[len(GreenRatings)-1]
+}
+
+// FindGreenRating returns the rating with the given grade, or the neutral one.
+func FindGreenRating(grade string) GreenRating {
+	for _, r := range GreenRatings {
+		if r.Grade == grade {
+			return r
+		}
+	}
+	return FindGreenRating(NeutralGreenRating)
+}
+
+// GreenDemandFactor scales demand for a service by the operator's green
+// rating; only eco-conscious passengers react to it.
+func GreenDemandFactor(grade, serviceType string) float64 {
+	return 1 + ecoConsciousShare[serviceType]*FindGreenRating(grade).DemandBonus
+}
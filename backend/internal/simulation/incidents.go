@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Incident types.
+const (
+	IncidentTrafficJam = "traffic_jam"
+	IncidentFlood      = "flood"
+	IncidentLandslide  = "landslide"
+	IncidentAccident   = "accident"
+	IncidentBreakdown  = "breakdown"
+)
+
+// incidentSeedSalt keeps incident draws independent from other per-trip
+// random streams seeded by the trip ID.
+const incidentSeedSalt = 0x5eed1c
+
+// injuryCompensation is paid to every injured passenger.
+const injuryCompensation = 25000000.0 // IDR
+
+// roadClosurePerLevel is how long a closed road takes to clear per severity level.
+const roadClosurePerLevel = 12 * time.Hour // game time
+
+// RoadClosure is how long an incident of the given severity closes the road.
+func RoadClosure(severity int) time.Duration {
+	return time.Duration(severity) * roadClosurePerLevel
+}
+
+type incidentProfile struct {
+	per100Km     float64 // chance per 100 km in ideal conditions
+	rain         float64 // multiplier when it rains on the route
+	night        float64 // multiplier for night service
+	minDelay     int     // minutes
+	maxDelay     int     // minutes
+	costPerLevel float64 // IDR per severity level
+	damage       float64 // bus condition lost per severity level
+}
+
+var incidentProfiles = map[string]incidentProfile{
+	IncidentTrafficJam: {per100Km: 0.06, rain: 1.3, night: 0.4, minDelay: 15, maxDelay: 90, costPerLevel: 150000},
+	IncidentFlood:      {per100Km: 0.002, rain: 4, night: 1, minDelay: 60, maxDelay: 240, costPerLevel: 2000000, damage: 5},
+	IncidentLandslide:  {per100Km: 0.0015, rain: 4, night: 1, minDelay: 120, maxDelay: 360, costPerLevel: 1500000},
+	IncidentAccident:   {per100Km: 0.001, rain: 1.5, night: 1.8, minDelay: 30, maxDelay: 180, costPerLevel: 15000000, damage: 12},
+	IncidentBreakdown:  {per100Km: 0.01, rain: 1, night: 1, minDelay: 60, maxDelay: 240, costPerLevel: 1000000},
+}
+
+// incidentOrder fixes the draw order so the same seed gives the same incidents.
+var incidentOrder = []string{IncidentTrafficJam, IncidentFlood, IncidentLandslide, IncidentAccident, IncidentBreakdown}
+
+// IncidentConditions describes a trip for the incident generator.
+type IncidentConditions struct {
+	DistanceKm float64
+	Condition  float64 // bus condition 0-100
+	Night      bool
+	Rain       bool // it rains somewhere along the route; each type has its own rain multiplier
+	Passengers int
+	// Risk multiplies every incident probability; zero means 1. Weather is
+	// accounted for through Rain only.
+	Risk float64
+}
+
+// GeneratedIncident is an event that will happen at a point along a trip.
+type GeneratedIncident struct {
+	Type         string  `json:"type"`
+	Severity     int     `json:"severity"` // 1 minor - 3 severe
+	At           float64 `json:"at"`       // fraction of the route, 0-1
+	DelayMinutes int     `json:"delay_minutes"`
+	Cost         float64 `json:"cost"`         // IDR, repairs, towing, detours and compensation
+	Compensation float64 `json:"compensation"` // part of the cost paid to injured passengers
+	Damage       float64 `json:"damage"`       // bus condition lost
+	Injuries     int     `json:"injuries"`
+	RoadClosed   bool    `json:"road_closed"`
+}
+
+// GenerateIncidents draws the incidents of a trip. The same seed and
+// conditions always produce the same incidents.
+func GenerateIncidents(seed int64, c IncidentConditions) []GeneratedIncident {
+	rng := rand.New(rand.NewSource(seed ^ incidentSeedSalt))
+	risk := c.Risk
+	if risk <= 0 {
+		risk = 1
+	}
+
+	// Worn-out buses are more likely to end up in accidents
+	wear := 1 + math.Max(0, 60-c.Condition)/30
+
+	var incidents []GeneratedIncident
+	for _, kind := range incidentOrder {
+		p := incidentProfiles[kind]
+		chance := p.per100Km * c.DistanceKm / 100 * risk
+		if c.Rain {
+			chance *= p.rain
+		}
+		if c.Night {
+			chance *= p.night
+		}
+		switch kind {
+		case IncidentAccident:
+			chance *= wear
+		case IncidentBreakdown:
+			chance *= 1 + math.Max(0, 70-c.Condition)/10
+		}
+
+		// Draw every value even when nothing happens to keep the stream stable
+		roll, at, severityRoll, delayRoll, injuryRoll := rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64(), rng.Float64()
+		if roll >= math.Min(chance, 0.95) {
+			continue
+		}
+
+		severity := 1
+		switch {
+		case severityRoll > 0.9:
+			severity = 3
+		case severityRoll > 0.6:
+			severity = 2
+		}
+
+		span := float64(p.maxDelay - p.minDelay)
+		incident := GeneratedIncident{
+			Type:         kind,
+			Severity:     severity,
+			At:           0.05 + at*0.9,
+			DelayMinutes: p.minDelay + int(span*(float64(severity-1)+delayRoll)/3),
+			Cost:         p.costPerLevel * float64(severity),
+			Damage:       p.damage * float64(severity),
+			RoadClosed:   kind == IncidentLandslide || (kind == IncidentFlood && severity == 3),
+		}
+		if kind == IncidentAccident && c.Passengers > 0 {
+			incident.Injuries = int(math.Round(injuryRoll * float64(severity) / 3 * float64(c.Passengers) * 0.3))
+			incident.Compensation = float64(incident.Injuries) * injuryCompensation
+			incident.Cost += incident.Compensation
+		}
+		incidents = append(incidents, incident)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].At < incidents[j].At })
+	return incidents
+}
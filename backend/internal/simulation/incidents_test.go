@@ -0,0 +1,70 @@
+package simulation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateIncidentsIsSeeded(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions IncidentConditions
+		varies     bool // other seeds give other incidents
+	}{
+		{name: "short day trip", conditions: IncidentConditions{DistanceKm: 150, Condition: 90, Passengers: 30}},
+		{name: "long night trip in the rain", conditions: IncidentConditions{DistanceKm: 900, Condition: 80, Night: true, Rain: true, Passengers: 40}},
+		{name: "worn bus on a risky road", conditions: IncidentConditions{DistanceKm: 600, Condition: 25, Passengers: 45, Risk: 20}, varies: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline := GenerateIncidents(0, tt.conditions)
+			varied := false
+			for seed := int64(1); seed <= 200; seed++ {
+				first := GenerateIncidents(seed, tt.conditions)
+				second := GenerateIncidents(seed, tt.conditions)
+				if !reflect.DeepEqual(first, second) {
+					t.Fatalf("seed %d gave %+v, then %+v", seed, first, second)
+				}
+				varied = varied || !reflect.DeepEqual(first, baseline)
+			}
+			if tt.varies && !varied {
+				t.Errorf("every seed gave the incidents of seed 0")
+			}
+		})
+	}
+}
+
+func TestGenerateIncidents(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions IncidentConditions
+		wantAny    bool
+	}{
+		{name: "no distance", conditions: IncidentConditions{DistanceKm: 0, Condition: 20, Night: true, Rain: true, Passengers: 40, Risk: 20}},
+		{name: "worn bus on a risky road", conditions: IncidentConditions{DistanceKm: 600, Condition: 25, Passengers: 45, Risk: 20}, wantAny: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			for seed := int64(1); seed <= 50; seed++ {
+				for _, incident := range GenerateIncidents(seed, tt.conditions) {
+					count++
+					if incident.Severity < 1 || incident.Severity > 3 {
+						t.Errorf("seed %d: severity %d out of range", seed, incident.Severity)
+					}
+					if incident.At < 0 || incident.At > 1 {
+						t.Errorf("seed %d: incident at %.2f of the route", seed, incident.At)
+					}
+					if incident.Compensation > incident.Cost {
+						t.Errorf("seed %d: compensation %.0f exceeds the cost %.0f", seed, incident.Compensation, incident.Cost)
+					}
+				}
+			}
+			if tt.wantAny != (count > 0) {
+				t.Errorf("generated %d incidents over 50 seeds, want any: %v", count, tt.wantAny)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package simulation
+
+import "math"
+
+// Insurance coverages a bus can be insured for.
+const (
+	CoverageCollision = "collision"
+	CoverageTowing    = "towing"
+	CoverageLiability = "liability"
+)
+
+// InsuranceProduct is a policy type with its terms.
+type InsuranceProduct struct {
+	Coverage    string  `json:"coverage"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Deductible  float64 `json:"deductible"` // IDR the company pays itself per claim
+	// Limit is the most paid per claim; 0 means up to the bus value.
+	Limit float64 `json:"limit"`
+}
+
+var InsuranceProducts = []InsuranceProduct{
+	{CoverageCollision, "Collision", "Repairs after accidents and flood damage, up to the bus value", 5000000, 0},
+	{CoverageTowing, "Breakdown towing", "Towing and roadside repairs after breakdowns", 250000, 5000000},
+	{CoverageLiability, "Passenger liability", "Compensation for passengers injured on board", 0, 500000000},
+}
+
+func FindInsuranceProduct(coverage string) (InsuranceProduct, bool) {
+	for _, p := range InsuranceProducts {
+		if p.Coverage == coverage {
+			return p, true
+		}
+	}
+	return InsuranceProduct{}, false
+}
+
+// Weekly base premiums before the claim history adjustment.
+const (
+	collisionPremiumRate    = 0.0015 // share of the bus value
+	towingPremium           = 75000  // IDR
+	liabilityPremiumPerSeat = 20000  // IDR
+)
+
+// WeeklyPremium prices a policy for a bus. recentClaims is the number of paid
+// claims the company made on the same coverage in the last year; a clean
+// record earns a discount and every claim loads the premium.
+func WeeklyPremium(coverage string, busValue float64, capacity, recentClaims int) float64 {
+	var base float64
+	switch coverage {
+	case CoverageCollision:
+		base = busValue * collisionPremiumRate
+	case CoverageTowing:
+		base = towingPremium
+	case CoverageLiability:
+		base = float64(capacity) * liabilityPremiumPerSeat
+	}
+	return math.Round(base * PremiumMultiplier(recentClaims))
+}
+
+// PremiumMultiplier adjusts premiums for claim history.
+func PremiumMultiplier(recentClaims int) float64 {
+	if recentClaims == 0 {
+		return 0.9
+	}
+	return math.Min(2, 1+0.2*float64(recentClaims))
+}
+
+// ClaimableAmounts splits the cost of an incident by the coverage that pays
+// for it. Traffic jams and landslides are not insurable.
+func ClaimableAmounts(incidentType string, cost, compensation float64) map[string]float64 {
+	amounts := map[string]float64{}
+	switch incidentType {
+	case IncidentAccident:
+		amounts[CoverageCollision] = cost - compensation
+		amounts[CoverageLiability] = compensation
+	case IncidentFlood:
+		amounts[CoverageCollision] = cost
+	case IncidentBreakdown:
+		amounts[CoverageTowing] = cost
+	}
+	for coverage, amount := range amounts {
+		if amount <= 0 {
+			delete(amounts, coverage)
+		}
+	}
+	return amounts
+}
+
+// ClaimPayout is what the insurer pays on a claim after the deductible and
+// the limit; limit 0 means no limit.
+func ClaimPayout(amount, deductible, limit float64) float64 {
+	payout := math.Max(0, amount-deductible)
+	if limit > 0 {
+		payout = math.Min(payout, limit)
+	}
+	return payout
+}
@@ -0,0 +1,114 @@
+package simulation
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Campaign target types.
+const (
+	TargetCity  = "city"
+	TargetRoute = "route"
+)
+
+// MaxMarketingBoost caps the combined demand boost of all campaigns on a route.
+const MaxMarketingBoost = 0.4
+
+// MarketingChannel is a medium campaigns can be bought on. The demand boost
+// grows with the daily budget and saturates at MaxBoost; HalfBudget buys half
+// of it.
+type MarketingChannel struct {
+	Channel        string   `json:"channel"`
+	Name           string   `json:"name"`
+	Targets        []string `json:"targets"`
+	MaxBoost       float64  `json:"max_boost"`
+	HalfBudget     float64  `json:"half_budget"`      // IDR per day
+	MinDailyBudget float64  `json:"min_daily_budget"` // IDR
+}
+
+var MarketingChannels = []MarketingChannel{
+	{"radio", "Radio spots", []string{TargetCity}, 0.15, 400000, 100000},
+	{"billboard", "Billboards", []string{TargetCity, TargetRoute}, 0.10, 250000, 150000},
+	{"social_media", "Social media ads", []string{TargetCity, TargetRoute}, 0.20, 600000, 50000},
+}
+
+func FindMarketingChannel(channel string) (MarketingChannel, bool) {
+	for _, c := range MarketingChannels {
+		if c.Channel == channel {
+			return c, true
+		}
+	}
+	return MarketingChannel{}, false
+}
+
+// CanTarget reports whether the channel can target the given target type.
+func (c MarketingChannel) CanTarget(target string) bool {
+	for _, t := range c.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// CampaignBoost is the demand boost a daily budget buys on the channel.
+func (c MarketingChannel) CampaignBoost(dailyBudget float64) float64 {
+	boost := c.MaxBoost * (1 - math.Pow(2, -dailyBudget/c.HalfBudget))
+	return math.Round(boost*1000) / 1000
+}
+
+// AdvertisingWeek is the period livery advertising is paid by.
+const AdvertisingWeek = 7 * GameDay
+
+// AdFullWeekKm is the distance a bus must drive in a week to earn the full
+// weekly livery payment; advertisers pay pro rata for less.
+const AdFullWeekKm = 3000.0
+
+// AdPayment is the livery payment for a week in which the bus drove km.
+func AdPayment(weeklyPayment, km float64) float64 {
+	return math.Round(weeklyPayment * math.Min(km/AdFullWeekKm, 1))
+}
+
+var advertisers = []string{
+	"Indomie", "Telkomsel", "Kopi Kapal Api", "Tokopedia", "Gojek",
+	"Bank BRI", "Aqua", "Traveloka", "Teh Botol Sosro", "Shopee",
+}
+
+var adTerms = []int{4, 8, 12} // weeks
+
+// adWeeklyRate is the weekly livery rent by bus class in IDR.
+var adWeeklyRate = map[string]float64{
+	BusClassSmall:  250000,
+	BusClassMedium: 400000,
+	BusClassLarge:  600000,
+}
+
+// AdOffer is an advertiser's offer to rent a bus livery.
+type AdOffer struct {
+	Advertiser    string  `json:"advertiser"`
+	Weeks         int     `json:"weeks"`
+	WeeklyPayment float64 `json:"weekly_payment"` // IDR for a full week of driving
+}
+
+// AdOffers lists the advertisers bidding for a bus's livery this game week.
+// Offers are seeded by bus and week, so they stay the same all week.
+func AdOffers(busID uint, capacity int, now time.Time) []AdOffer {
+	week := int(now.Sub(GameStart) / AdvertisingWeek)
+	h := fnv.New64a()
+	h.Write([]byte("ads|" + strconv.FormatUint(uint64(busID), 10) + "|" + strconv.Itoa(week)))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	rate := adWeeklyRate[BusClass(capacity)]
+	offers := make([]AdOffer, 0, 3)
+	for _, i := range rng.Perm(len(advertisers))[:3] {
+		offers = append(offers, AdOffer{
+			Advertiser:    advertisers[i],
+			Weeks:         adTerms[rng.Intn(len(adTerms))],
+			WeeklyPayment: math.Round(rate*(0.8+0.5*rng.Float64())/10000) * 10000,
+		})
+	}
+	return offers
+}
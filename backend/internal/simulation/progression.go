@@ -0,0 +1,103 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Progression is the experience rules and level curve loaded from configuration.
+type Progression struct {
+	XP     ExperienceRules `json:"xp"`
+	Levels []Level         `json:"levels"`
+}
+
+type ExperienceRules struct {
+	PerTrip                float64 `json:"per_trip"`
+	PerPassenger           float64 `json:"per_passenger"`
+	Per10Km                float64 `json:"per_10_km"`
+	OnTimeBonus            float64 `json:"on_time_bonus"`
+	OnTimeToleranceMinutes int     `json:"on_time_tolerance_minutes"`
+}
+
+// Level lists the experience needed to reach a level and everything unlocked at it.
+type Level struct {
+	Level               int      `json:"level"`
+	XP                  int      `json:"xp"`
+	MaxDepots           int      `json:"max_depots"`
+	BusTypes            []string `json:"bus_types"`
+	InterprovinceRoutes bool     `json:"interprovince_routes"`
+	NightService        bool     `json:"night_service"`
+}
+
+func ParseProgression(raw []byte) (*Progression, error) {
+	var p Progression
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse progression: %w", err)
+	}
+	if len(p.Levels) == 0 {
+		return nil, fmt.Errorf("progression needs at least one level")
+	}
+
+	sort.Slice(p.Levels, func(i, j int) bool { return p.Levels[i].Level < p.Levels[j].Level })
+	for i := 1; i < len(p.Levels); i++ {
+		if p.Levels[i].XP <= p.Levels[i-1].XP {
+			return nil, fmt.Errorf("level %d must need more experience than level %d", p.Levels[i].Level, p.Levels[i-1].Level)
+		}
+	}
+	return &p, nil
+}
+
+// TripExperience is the experience earned for completing a trip.
+func (p *Progression) TripExperience(passengers int, distanceKm float64, delayMinutes int) int {
+	xp := p.XP.PerTrip + float64(passengers)*p.XP.PerPassenger + distanceKm/10*p.XP.Per10Km
+	if delayMinutes <= p.XP.OnTimeToleranceMinutes {
+		xp += p.XP.OnTimeBonus
+	}
+	return int(xp)
+}
+
+// LevelFor returns the highest level reached with the given experience.
+func (p *Progression) LevelFor(xp int) Level {
+	current := p.Levels[0]
+	for _, level := range p.Levels {
+		if xp >= level.XP {
+			current = level
+		}
+	}
+	return current
+}
+
+// Unlocks returns the level entry describing what a company at level may use.
+func (p *Progression) Unlocks(level int) Level {
+	current := p.Levels[0]
+	for _, l := range p.Levels {
+		if l.Level <= level {
+			current = l
+		}
+	}
+	return current
+}
+
+// Next returns the level after the given one, if any.
+func (p *Progression) Next(level int) (Level, bool) {
+	for _, l := range p.Levels {
+		if l.Level > level {
+			return l, true
+		}
+	}
+	return Level{}, false
+}
+
+// BusTypeLevel returns the first level that unlocks a bus type, or false when
+// no level does.
+func (p *Progression) BusTypeLevel(busType string) (int, bool) {
+	for _, l := range p.Levels {
+		for _, t := range l.BusTypes {
+			if t == busType {
+				return l.Level, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package simulation
+
+import "math"
+
+// ReputationBaseline is the neutral reputation new companies start at and every
+// score drifts back toward over time.
+const ReputationBaseline = 50.0
+
+const (
+	reputationLearningRate = 0.1  // weight of a single trip in the running score
+	reputationDailyDecay   = 0.02 // share of the gap to baseline closed per game day
+	punctualityTolerance   = 15   // minutes late still counted as on time
+	punctualityPenalty     = 2.0  // points lost per minute beyond the tolerance
+)
+
+// TripOutcome captures what passengers experienced on a trip.
+type TripOutcome struct {
+	DelayMinutes int
+	PeakLoad     int // most passengers on board at once
+	Capacity     int
+	Condition    float64
+	Amenities    int // installed bus upgrades
+	Breakdown    bool
+	Accident     bool
+	Injuries     int
+	Cancelled    bool
+	Satisfaction float64 // aggregated passenger satisfaction, 0-100
+}
+
+// ReputationScores are 0-100 component scores and their weighted overall score.
+type ReputationScores struct {
+	Punctuality  float64 `json:"punctuality"`
+	Reliability  float64 `json:"reliability"`
+	LoadFactor   float64 `json:"load_factor"`
+	Condition    float64 `json:"condition"`
+	Amenities    float64 `json:"amenities"`
+	Satisfaction float64 `json:"satisfaction"`
+	Overall      float64 `json:"overall"`
+}
+
+// ScoreTrip rates a single trip.
+func ScoreTrip(o TripOutcome) ReputationScores {
+	var s ReputationScores
+
+	late := math.Max(0, float64(o.DelayMinutes-punctualityTolerance))
+	s.Punctuality = clampScore(100 - late*punctualityPenalty)
+
+	s.Reliability = 100
+	switch {
+	case o.Cancelled || o.Accident:
+		s.Reliability = 0
+	case o.Breakdown:
+		s.Reliability = 20
+	}
+	s.Reliability = clampScore(s.Reliability - float64(o.Injuries)*10)
+
+	// Passengers like a comfortably full bus; empty buses look unreliable and
+	// overcrowding is uncomfortable
+	s.LoadFactor = 100
+	if o.Capacity > 0 {
+		load := float64(o.PeakLoad) / float64(o.Capacity)
+		switch {
+		case load > 0.95:
+			s.LoadFactor = 70
+		case load < 0.3:
+			s.LoadFactor = 50 + load/0.3*50
+		}
+	}
+
+	s.Condition = clampScore(o.Condition)
+	s.Amenities = clampScore(50 + float64(o.Amenities)*10)
+	s.Satisfaction = clampScore(o.Satisfaction)
+	if o.Cancelled {
+		// Work that never ran says nothing about the bus
+		s.Punctuality, s.LoadFactor, s.Satisfaction = 0, 0, 0
+		s.Condition, s.Amenities = ReputationBaseline, ReputationBaseline
+	}
+
+	s.Overall = s.Punctuality*0.25 + s.Reliability*0.2 + s.LoadFactor*0.15 + s.Condition*0.1 + s.Amenities*0.1 + s.Satisfaction*0.2
+	return s
+}
+
+// BlendReputation folds a trip score into a running reputation score.
+func BlendReputation(current, sample float64) float64 {
+	return clampScore(current + (sample-current)*reputationLearningRate)
+}
+
+// DecayReputation moves a score toward the baseline over the given game days.
+func DecayReputation(score, days float64) float64 {
+	remaining := math.Pow(1-reputationDailyDecay, days)
+	return ReputationBaseline + (score-ReputationBaseline)*remaining
+}
+
+// DemandFactor scales route demand by reputation: baseline reputation leaves
+// demand unchanged, 0 cuts it by 30% and 100 raises it by 30%.
+func DemandFactor(reputation float64) float64 {
+	return 0.7 + 0.6*clampScore(reputation)/100
+}
+
+func clampScore(v float64) float64 {
+	return math.Max(0, math.Min(100, v))
+}
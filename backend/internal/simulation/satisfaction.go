@@ -0,0 +1,203 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ReferenceFarePerKm is what passengers expect to pay per km on a normal bus.
+const ReferenceFarePerKm = 300.0 // IDR
+
+// busTypeComfort is the base comfort of each bus type; passengers also accept
+// paying proportionally more for it.
+var busTypeComfort = map[string]float64{
+	"normal":            50,
+	"high_decker":       62,
+	"super_high_decker": 72,
+	"double_decker":     78,
+}
+
+var serviceTypeComfort = map[string]float64{
+	"business":  5,
+	"executive": 10,
+	"night":     5,
+}
+
+// SatisfactionInput is what passengers noticed on a trip.
+type SatisfactionInput struct {
+	DelayMinutes int
+	BusType      string
+	ServiceType  string
+	Upgrades     int
+	Condition    float64
+	PeakLoad     int
+	Capacity     int
+	FarePerKm    float64
+	// FareTolerance scales the fare passengers expect, e.g. during holidays.
+	FareTolerance float64
+}
+
+// Satisfaction is the aggregated 0-100 passenger satisfaction with a trip.
+type Satisfaction struct {
+	Punctuality float64 `json:"punctuality"`
+	Comfort     float64 `json:"comfort"`
+	Crowding    float64 `json:"crowding"`
+	Price       float64 `json:"price"`
+	Overall     float64 `json:"overall"`
+}
+
+// ScoreSatisfaction aggregates how passengers felt about a trip.
+func ScoreSatisfaction(in SatisfactionInput) Satisfaction {
+	var s Satisfaction
+
+	late := math.Max(0, float64(in.DelayMinutes-punctualityTolerance))
+	s.Punctuality = clampScore(100 - late*punctualityPenalty)
+
+	base, ok := busTypeComfort[in.BusType]
+	if !ok {
+		base = busTypeComfort["normal"]
+	}
+	amenities := math.Min(20, float64(in.Upgrades)*5)
+	s.Comfort = clampScore((base+serviceTypeComfort[in.ServiceType]+amenities)*0.6 + in.Condition*0.4)
+
+	// Nobody minds until the bus is 70% full; a packed bus is unpleasant
+	s.Crowding = 100
+	if in.Capacity > 0 {
+		load := float64(in.PeakLoad) / float64(in.Capacity)
+		if load > 0.7 {
+			s.Crowding = clampScore(100 - (load-0.7)*200)
+		}
+	}
+
+	// Price is judged against what the bus type is worth
+	s.Price = 70
+	expected := ReferenceFarePerKm * (base / busTypeComfort["normal"])
+	if in.FareTolerance > 0 {
+		expected *= in.FareTolerance
+	}
+	if in.FarePerKm > 0 {
+		ratio := in.FarePerKm / expected
+		if ratio <= 1 {
+			s.Price = clampScore(70 + (1-ratio)*100)
+		} else {
+			s.Price = clampScore(70 - (ratio-1)*150)
+		}
+	}
+
+	s.Overall = s.Punctuality*0.3 + s.Comfort*0.3 + s.Crowding*0.2 + s.Price*0.2
+	return s
+}
+
+// ReviewTemplates are review sentences keyed by language, aspect and sentiment.
+// Templates may use the {route}, {bus} and {delay} placeholders.
+type ReviewTemplates map[string]map[string]map[string][]string
+
+// ReviewLanguages lists the review languages and the share of passengers writing in each.
+var ReviewLanguages = []struct {
+	Code  string
+	Share float64
+}{
+	{"id", 0.7},
+	{"en", 0.3},
+}
+
+var reviewAspects = []string{"punctuality", "comfort", "crowding", "price"}
+
+func ParseReviewTemplates(raw []byte) (ReviewTemplates, error) {
+	var t ReviewTemplates
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse review templates: %w", err)
+	}
+	for _, lang := range ReviewLanguages {
+		for _, aspect := range append(reviewAspects, "overall") {
+			for _, sentiment := range []string{"positive", "neutral", "negative"} {
+				if len(t[lang.Code][aspect][sentiment]) == 0 {
+					return nil, fmt.Errorf("missing %s %s %s review templates", lang.Code, aspect, sentiment)
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// Review is a passenger review generated for a trip.
+type Review struct {
+	Rating   int // 1-5 stars
+	Language string
+	Aspect   string
+	Text     string
+}
+
+// GenerateReviews writes reviews from a sample of the passengers on a trip.
+// The same rng seed always yields the same reviews.
+func (t ReviewTemplates) GenerateReviews(s Satisfaction, passengers int, rng *rand.Rand, route, bus string, delayMinutes int) []Review {
+	count := int(math.Min(5, math.Ceil(float64(passengers)/10)))
+	scores := map[string]float64{
+		"punctuality": s.Punctuality,
+		"comfort":     s.Comfort,
+		"crowding":    s.Crowding,
+		"price":       s.Price,
+	}
+	replacer := strings.NewReplacer("{route}", route, "{bus}", bus, "{delay}", strconv.Itoa(delayMinutes))
+
+	reviews := make([]Review, 0, count)
+	for i := 0; i < count; i++ {
+		rating := int(math.Round(s.Overall/20 + rng.NormFloat64()*0.6))
+		rating = int(math.Max(1, math.Min(5, float64(rating))))
+
+		// Unhappy passengers complain about the worst aspect, happy ones praise
+		// the best; the rest comment on something at random
+		aspect := reviewAspects[rng.Intn(len(reviewAspects))]
+		switch {
+		case rating <= 2:
+			aspect = extremeAspect(scores, false)
+		case rating >= 4 && rng.Float64() < 0.7:
+			aspect = extremeAspect(scores, true)
+		case rng.Float64() < 0.2:
+			aspect = "overall"
+		}
+
+		sentiment := "neutral"
+		switch {
+		case rating >= 4:
+			sentiment = "positive"
+		case rating <= 2:
+			sentiment = "negative"
+		}
+
+		language := pickLanguage(rng)
+		options := t[language][aspect][sentiment]
+		reviews = append(reviews, Review{
+			Rating:   rating,
+			Language: language,
+			Aspect:   aspect,
+			Text:     replacer.Replace(options[rng.Intn(len(options))]),
+		})
+	}
+	return reviews
+}
+
+func extremeAspect(scores map[string]float64, best bool) string {
+	pick := reviewAspects[0]
+	for _, aspect := range reviewAspects[1:] {
+		if (best && scores[aspect] > scores[pick]) || (!best && scores[aspect] < scores[pick]) {
+			pick = aspect
+		}
+	}
+	return pick
+}
+
+func pickLanguage(rng *rand.Rand) string {
+	r := rng.Float64()
+	for _, lang := range ReviewLanguages {
+		if r < lang.Share {
+			return lang.Code
+		}
+		r -= lang.Share
+	}
+	return ReviewLanguages[0].Code
+}
@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// AnyCity matches every city in a seasonal demand rule.
+const AnyCity = "*"
+
+// SeasonalEvent is a holiday period that changes travel demand. Yearly events
+// repeat on the same dates every year and may wrap over New Year.
+type SeasonalEvent struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Start        string `json:"start"` // YYYY-MM-DD, inclusive
+	End          string `json:"end"`   // YYYY-MM-DD, inclusive
+	Yearly       bool   `json:"yearly"`
+	AnnounceDays int    `json:"announce_days"`
+	// FareTolerance scales the fare passengers consider reasonable.
+	FareTolerance float64      `json:"fare_tolerance"`
+	Demand        []DemandRule `json:"demand"`
+
+	start, end time.Time
+}
+
+// DemandRule multiplies demand on routes running from one of the From cities
+// to one of the To cities. The first matching rule of an event applies.
+type DemandRule struct {
+	From       []string `json:"from"`
+	To         []string `json:"to"`
+	Multiplier float64  `json:"multiplier"`
+}
+
+// SeasonOccurrence is one dated instance of a seasonal event.
+type SeasonOccurrence struct {
+	*SeasonalEvent
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"` // exclusive
+	AnnounceAt  time.Time `json:"announce_at"`
+	Active      bool      `json:"active"`
+	DaysToStart int       `json:"days_to_start"`
+}
+
+// SeasonCalendar is the list of seasonal events loaded from configuration.
+type SeasonCalendar struct {
+	Events []*SeasonalEvent `json:"events"`
+}
+
+func ParseSeasonCalendar(raw []byte) (*SeasonCalendar, error) {
+	var c SeasonCalendar
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse season calendar: %w", err)
+	}
+
+	loc := GameStart.Location()
+	for _, e := range c.Events {
+		var err error
+		if e.start, err = time.ParseInLocation("2006-01-02", e.Start, loc); err != nil {
+			return nil, fmt.Errorf("event %q has an invalid start date: %w", e.ID, err)
+		}
+		if e.end, err = time.ParseInLocation("2006-01-02", e.End, loc); err != nil {
+			return nil, fmt.Errorf("event %q has an invalid end date: %w", e.ID, err)
+		}
+		if e.end.Before(e.start) && !e.Yearly {
+			return nil, fmt.Errorf("event %q ends before it starts", e.ID)
+		}
+		if e.FareTolerance == 0 {
+			e.FareTolerance = 1
+		}
+		for _, rule := range e.Demand {
+			if rule.Multiplier <= 0 {
+				return nil, fmt.Errorf("event %q has a non-positive demand multiplier", e.ID)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// Occurrences returns the event instances that are running at t or will be
+// announced within the given horizon, in start order.
+func (c *SeasonCalendar) Occurrences(t time.Time, horizon time.Duration) []SeasonOccurrence {
+	var list []SeasonOccurrence
+	for _, e := range c.Events {
+		o, ok := e.occurrence(t)
+		if !ok || o.StartsAt.After(t.Add(horizon)) {
+			continue
+		}
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartsAt.Before(list[j].StartsAt) })
+	return list
+}
+
+// Active returns the events running at t.
+func (c *SeasonCalendar) Active(t time.Time) []SeasonOccurrence {
+	var active []SeasonOccurrence
+	for _, o := range c.Occurrences(t, 0) {
+		if o.Active {
+			active = append(active, o)
+		}
+	}
+	return active
+}
+
+// DemandMultiplier is the combined demand multiplier of the events running at
+// t for a trip from origin to destination.
+func (c *SeasonCalendar) DemandMultiplier(t time.Time, origin, destination string) float64 {
+	multiplier := 1.0
+	for _, o := range c.Active(t) {
+		for _, rule := range o.Demand {
+			if matchesCity(rule.From, origin) && matchesCity(rule.To, destination) {
+				multiplier *= rule.Multiplier
+				break
+			}
+		}
+	}
+	return multiplier
+}
+
+// FareTolerance is how much more than usual passengers accept to pay at t.
+func (c *SeasonCalendar) FareTolerance(t time.Time) float64 {
+	tolerance := 1.0
+	for _, o := range c.Active(t) {
+		if o.FareTolerance > tolerance {
+			tolerance = o.FareTolerance
+		}
+	}
+	return tolerance
+}
+
+// occurrence finds the instance of the event that is running at t or starts
+// next. Fixed-date events in the past have none.
+func (e *SeasonalEvent) occurrence(t time.Time) (SeasonOccurrence, bool) {
+	start, end := e.start, e.end.AddDate(0, 0, 1)
+	if e.Yearly {
+		if !end.After(start) {
+			end = end.AddDate(1, 0, 0)
+		}
+		years := t.Year() - start.Year() - 1
+		start, end = start.AddDate(years, 0, 0), end.AddDate(years, 0, 0)
+		for !end.After(t) {
+			start, end = start.AddDate(1, 0, 0), end.AddDate(1, 0, 0)
+		}
+	} else if !end.After(t) {
+		return SeasonOccurrence{}, false
+	}
+
+	o := SeasonOccurrence{
+		SeasonalEvent: e,
+		StartsAt:      start,
+		EndsAt:        end,
+		AnnounceAt:    start.AddDate(0, 0, -e.AnnounceDays),
+		Active:        !t.Before(start),
+	}
+	if !o.Active {
+		o.DaysToStart = int(math.Ceil(start.Sub(t).Hours() / 24))
+	}
+	return o, true
+}
+
+func matchesCity(cities []string, city string) bool {
+	for _, c := range cities {
+		if c == AnyCity || c == city {
+			return true
+		}
+	}
+	return false
+}
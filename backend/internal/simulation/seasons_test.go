@@ -0,0 +1,123 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+const testSeasons = `{
+  "events": [
+    {"id": "natal_tahun_baru", "name": "Libur Natal dan Tahun Baru", "start": "2025-12-20", "end": "2026-01-04", "yearly": true},
+    {"id": "waisak", "name": "Libur Waisak", "start": "2025-05-10", "end": "2025-05-13"}
+  ]
+}`
+
+func testCalendar(t *testing.T) *SeasonCalendar {
+	t.Helper()
+	c, err := ParseSeasonCalendar([]byte(testSeasons))
+	if err != nil {
+		t.Fatalf("ParseSeasonCalendar: %v", err)
+	}
+	return c
+}
+
+func wib(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, GameStart.Location())
+}
+
+func TestSeasonalEventOccurrence(t *testing.T) {
+	c := testCalendar(t)
+	newYear, waisak := c.Events[0], c.Events[1]
+
+	tests := []struct {
+		name        string
+		event       *SeasonalEvent
+		at          time.Time
+		ok          bool
+		startsAt    time.Time
+		endsAt      time.Time
+		active      bool
+		daysToStart int
+	}{
+		{
+			name: "yearly event before its first year", event: newYear, at: wib(2025, time.June, 1, 0), ok: true,
+			startsAt: wib(2025, time.December, 20, 0), endsAt: wib(2026, time.January, 5, 0), daysToStart: 202,
+		},
+		{
+			name: "yearly event running in December", event: newYear, at: wib(2025, time.December, 25, 12), ok: true,
+			startsAt: wib(2025, time.December, 20, 0), endsAt: wib(2026, time.January, 5, 0), active: true,
+		},
+		{
+			name: "yearly event running after New Year", event: newYear, at: wib(2027, time.January, 2, 8), ok: true,
+			startsAt: wib(2026, time.December, 20, 0), endsAt: wib(2027, time.January, 5, 0), active: true,
+		},
+		{
+			name: "yearly event on its last day", event: newYear, at: wib(2027, time.January, 4, 23), ok: true,
+			startsAt: wib(2026, time.December, 20, 0), endsAt: wib(2027, time.January, 5, 0), active: true,
+		},
+		{
+			name: "yearly event after it ended", event: newYear, at: wib(2027, time.January, 5, 0), ok: true,
+			startsAt: wib(2027, time.December, 20, 0), endsAt: wib(2028, time.January, 5, 0), daysToStart: 349,
+		},
+		{
+			name: "fixed event ahead", event: waisak, at: wib(2025, time.May, 1, 0), ok: true,
+			startsAt: wib(2025, time.May, 10, 0), endsAt: wib(2025, time.May, 14, 0), daysToStart: 9,
+		},
+		{
+			name: "fixed event on its last day", event: waisak, at: wib(2025, time.May, 13, 18), ok: true,
+			startsAt: wib(2025, time.May, 10, 0), endsAt: wib(2025, time.May, 14, 0), active: true,
+		},
+		{name: "fixed event just ended", event: waisak, at: wib(2025, time.May, 14, 0)},
+		{name: "fixed event a year later", event: waisak, at: wib(2026, time.May, 11, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := tt.event.occurrence(tt.at)
+			if ok != tt.ok {
+				t.Fatalf("occurrence ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !o.StartsAt.Equal(tt.startsAt) || !o.EndsAt.Equal(tt.endsAt) {
+				t.Errorf("occurrence runs %s to %s, want %s to %s", o.StartsAt, o.EndsAt, tt.startsAt, tt.endsAt)
+			}
+			if o.Active != tt.active {
+				t.Errorf("Active = %v, want %v", o.Active, tt.active)
+			}
+			if o.DaysToStart != tt.daysToStart {
+				t.Errorf("DaysToStart = %d, want %d", o.DaysToStart, tt.daysToStart)
+			}
+		})
+	}
+}
+
+func TestSeasonCalendarActive(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []string
+	}{
+		{name: "New Year holiday", at: wib(2026, time.January, 1, 10), want: []string{"natal_tahun_baru"}},
+		{name: "Waisak", at: wib(2025, time.May, 11, 10), want: []string{"waisak"}},
+		{name: "past Waisak", at: wib(2026, time.May, 11, 10)},
+		{name: "no holiday", at: wib(2025, time.September, 1, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := c.Active(tt.at)
+			if len(active) != len(tt.want) {
+				t.Fatalf("Active returned %d events, want %v", len(active), tt.want)
+			}
+			for i, o := range active {
+				if o.ID != tt.want[i] {
+					t.Errorf("Active[%d] = %s, want %s", i, o.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package simulation
+
+// Bus classes toll operators and ferries price by.
+const (
+	BusClassSmall  = "small"  // up to 30 seats, Golongan I / V-A
+	BusClassMedium = "medium" // up to 50 seats, Golongan II / VI-A
+	BusClassLarge  = "large"  // double deckers and the like, Golongan III / VI-B
+)
+
+// Route charge kinds.
+const (
+	ChargeToll  = "toll"
+	ChargeFerry = "ferry"
+)
+
+// BusClass returns the pricing class of a bus with the given seat count.
+func BusClass(capacity int) string {
+	switch {
+	case capacity <= 30:
+		return BusClassSmall
+	case capacity <= 50:
+		return BusClassMedium
+	}
+	return BusClassLarge
+}
+
+// ClassFees is a fee per bus class in IDR.
+type ClassFees struct {
+	Small  float64 `json:"small"`
+	Medium float64 `json:"medium"`
+	Large  float64 `json:"large"`
+}
+
+// For returns the fee for a bus class.
+func (f ClassFees) For(class string) float64 {
+	switch class {
+	case BusClassSmall:
+		return f.Small
+	case BusClassMedium:
+		return f.Medium
+	}
+	return f.Large
+}
@@ -0,0 +1,126 @@
+package simulation
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+// Weather conditions, from best to worst.
+const (
+	WeatherClear     = "clear"
+	WeatherCloudy    = "cloudy"
+	WeatherRain      = "rain"
+	WeatherHeavyRain = "heavy_rain"
+)
+
+// monthlyRainChance is the daily chance of rain in each month on Java: the
+// rainy season runs from November to March and the dry season from May to
+// September.
+var monthlyRainChance = [12]float64{0.75, 0.75, 0.7, 0.55, 0.35, 0.2, 0.15, 0.12, 0.18, 0.35, 0.6, 0.72}
+
+// cityRainFactor scales the rain chance of cities wetter or drier than average.
+var cityRainFactor = map[string]float64{
+	"Bogor":      1.35, // the "rain city"
+	"Bandung":    1.15,
+	"Malang":     1.1,
+	"Purwokerto": 1.15,
+	"Surabaya":   0.85,
+	"Serang":     0.9,
+	"Banyuwangi": 0.85,
+}
+
+// Weather is the weather of a city on one game day.
+type Weather struct {
+	City       string  `json:"city"`
+	Date       string  `json:"date"`
+	Season     string  `json:"season"` // dry, rainy
+	Condition  string  `json:"condition"`
+	RainChance float64 `json:"rain_chance"`
+}
+
+// WeatherAt returns the weather of a city on the game day containing t. It is
+// derived from the city and date only, so it never changes once computed.
+func WeatherAt(city string, t time.Time) Weather {
+	day := t.In(GameStart.Location())
+	date := day.Format("2006-01-02")
+
+	chance := monthlyRainChance[day.Month()-1]
+	if factor, ok := cityRainFactor[city]; ok {
+		chance *= factor
+	}
+	if chance > 0.95 {
+		chance = 0.95
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(city + "|" + date))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	w := Weather{City: city, Date: date, Season: "dry", RainChance: chance}
+	if day.Month() >= time.November || day.Month() <= time.March {
+		w.Season = "rainy"
+	}
+
+	roll := rng.Float64()
+	switch {
+	case roll < chance*0.3:
+		w.Condition = WeatherHeavyRain
+	case roll < chance:
+		w.Condition = WeatherRain
+	case roll < chance+(1-chance)*0.4:
+		w.Condition = WeatherCloudy
+	default:
+		w.Condition = WeatherClear
+	}
+	return w
+}
+
+// Raining reports whether it rains.
+func (w Weather) Raining() bool {
+	return w.Condition == WeatherRain || w.Condition == WeatherHeavyRain
+}
+
+// SpeedFactor multiplies travel time.
+func (w Weather) SpeedFactor() float64 {
+	switch w.Condition {
+	case WeatherRain:
+		return 1.15
+	case WeatherHeavyRain:
+		return 1.35
+	}
+	return 1
+}
+
+// DemandFactor multiplies travel demand; fewer people set out in heavy rain.
+func (w Weather) DemandFactor() float64 {
+	switch w.Condition {
+	case WeatherRain:
+		return 0.95
+	case WeatherHeavyRain:
+		return 0.85
+	}
+	return 1
+}
+
+// RouteWeather is the weather along a route on the game day containing t,
+// one report per stop city.
+func RouteWeather(cities []string, t time.Time) []Weather {
+	reports := make([]Weather, len(cities))
+	for i, city := range cities {
+		reports[i] = WeatherAt(city, t)
+	}
+	return reports
+}
+
+// WorstWeather returns the report with the worst conditions.
+func WorstWeather(reports []Weather) Weather {
+	var worst Weather
+	rank := map[string]int{WeatherClear: 1, WeatherCloudy: 2, WeatherRain: 3, WeatherHeavyRain: 4}
+	for _, w := range reports {
+		if rank[w.Condition] > rank[worst.Condition] {
+			worst = w
+		}
+	}
+	return worst
+}
@@ -342,7 +342,7 @@ const DepotSetup: React.FC<{ onCreateDepot: (name: string, lat: number, lng: num
                 <div className="bg-blue-50 p-4 rounded-lg">
                   <h3 className="font-semibold text-blue-900 mb-2">Starting Bonus:</h3>
                   <ul className="text-sm text-blue-800 space-y-1">
-                    <li>• Starting capital: Rp 1,000,000</li>
+                    <li>• Starting capital: Rp 2,500,000,000</li>
                     <li>• Free small bus (25 seats)</li>
                     <li>• Basic depot (10 bus capacity)</li>
                   </ul>
@@ -105,7 +105,27 @@ export interface Trip {
 export interface Transaction {
   id: number;
   company_id: number;
-  type: 'income' | 'expense';
+  type:
+    | 'income'
+    | 'expense'
+    | 'purchase'
+    | 'sale'
+    | 'reward'
+    | 'incident'
+    | 'insurance'
+    | 'toll'
+    | 'ferry'
+    | 'electricity'
+    | 'fuel'
+    | 'carbon_tax'
+    | 'subsidy'
+    | 'penalty'
+    | 'charter'
+    | 'cargo'
+    | 'marketing'
+    | 'advertising'
+    | 'fare'
+    | 'operating';
   description: string;
   amount: number;
   balance: number;
@@ -141,10 +161,11 @@ export interface CreateDepotRequest {
 
 export interface CreateBusRequest {
   name: string;
-  type: string;
-  capacity: number;
+  model?: string;
+  type?: string;
+  capacity?: number;
   service_type: string;
-  purchase_price: number;
+  depot_id?: number;
 }
 
 export interface CreateTripRequest {